@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ConfigFileName is the name of the optional project-level configuration file
+const ConfigFileName = ".zed-test-toggle.json"
+
+// Config holds project-level overrides for the source/test path mapping.
+// Any field left empty falls back to the built-in gem/Rails heuristics.
+type Config struct {
+	// SrcPaths lists the source roots, e.g. ["app", "lib"]
+	SrcPaths []string `json:"src_paths"`
+	// TestPaths lists the test roots, e.g. ["spec", "spec/lib"]
+	TestPaths []string `json:"test_paths"`
+	// TestSuffixes lists the test file suffixes; the first one is used
+	// when building a test path from a source path, e.g. ["_spec.rb"]
+	TestSuffixes []string `json:"test_suffixes"`
+	// TestPrefixes lists test file name prefixes, e.g. ["test_"]
+	TestPrefixes []string `json:"test_prefixes"`
+	// TestNaming selects how new test files are named: "suffix" appends
+	// the first test suffix, "prefix" prepends the first test prefix
+	TestNaming string `json:"test_naming"`
+	// Framework is the preferred test framework, "rspec" or "minitest",
+	// used to create new tests when a project has both
+	Framework string `json:"framework"`
+	// ControllerTests orders the kinds of controller tests by preference:
+	// "request", "controller" and "integration"
+	ControllerTests []string `json:"controller_tests"`
+	// FuzzyThreshold is the score, between 0 and 1, a fuzzy match needs to
+	// be opened directly when no candidate exists, see FuzzyMatches. Every
+	// match scores at least 0.5, so lower thresholds act as 0.5.
+	FuzzyThreshold *float64 `json:"fuzzy_threshold"`
+	// Editor selects the editor used to open files, see NewOpener
+	Editor string `json:"editor"`
+}
+
+// LoadConfig reads the configuration file from the project root.
+// A missing file is not an error and yields an empty Config.
+func LoadConfig(root string) (*Config, error) {
+	config := &Config{}
+
+	path := filepath.Join(root, ConfigFileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config, nil
+		}
+		return config, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, config); err != nil {
+		return &Config{}, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if config.TestNaming != "" && config.TestNaming != NamingSuffix && config.TestNaming != NamingPrefix {
+		return &Config{}, fmt.Errorf("parsing %s: test_naming must be %q or %q", path, NamingSuffix, NamingPrefix)
+	}
+	for _, kind := range config.ControllerTests {
+		if kind != ControllerTestRequest && kind != ControllerTestController && kind != ControllerTestIntegration {
+			return &Config{}, fmt.Errorf("parsing %s: unknown controller test kind %q", path, kind)
+		}
+	}
+	if config.Framework != "" && Framework(config.Framework) != RSpec && Framework(config.Framework) != Minitest {
+		return &Config{}, fmt.Errorf("parsing %s: framework must be %q or %q", path, RSpec, Minitest)
+	}
+	if threshold := config.FuzzyThreshold; threshold != nil && (*threshold < 0 || *threshold > 1) {
+		return &Config{}, fmt.Errorf("parsing %s: fuzzy_threshold must be between 0 and 1", path)
+	}
+	return config, nil
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		expectErr bool
+		expected  Config
+	}{
+		{
+			name:     "missing file",
+			expected: Config{},
+		},
+		{
+			name:    "full config",
+			content: `{"src_paths": ["src"], "test_paths": ["tests"], "test_suffixes": ["_check.rb"], "test_prefixes": ["check_"]}`,
+			expected: Config{
+				SrcPaths:     []string{"src"},
+				TestPaths:    []string{"tests"},
+				TestSuffixes: []string{"_check.rb"},
+				TestPrefixes: []string{"check_"},
+			},
+		},
+		{
+			name:     "prefix naming",
+			content:  `{"test_prefixes": ["test_"], "test_naming": "prefix"}`,
+			expected: Config{TestPrefixes: []string{"test_"}, TestNaming: "prefix"},
+		},
+		{
+			name:     "preferred framework",
+			content:  `{"framework": "minitest"}`,
+			expected: Config{Framework: "minitest"},
+		},
+		{
+			name:      "unknown framework",
+			content:   `{"framework": "cucumber"}`,
+			expectErr: true,
+		},
+		{
+			name:      "unknown naming",
+			content:   `{"test_naming": "camel"}`,
+			expectErr: true,
+		},
+		{
+			name:      "invalid json",
+			content:   `{"src_paths": [`,
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.content != "" {
+				writeFile(t, filepath.Join(dir, ConfigFileName), tt.content)
+			}
+
+			config, err := LoadConfig(dir)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("LoadConfig() expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() unexpected error: %v", err)
+			}
+
+			if !equalStrings(config.SrcPaths, tt.expected.SrcPaths) {
+				t.Errorf("SrcPaths = %v, want %v", config.SrcPaths, tt.expected.SrcPaths)
+			}
+			if !equalStrings(config.TestPaths, tt.expected.TestPaths) {
+				t.Errorf("TestPaths = %v, want %v", config.TestPaths, tt.expected.TestPaths)
+			}
+			if !equalStrings(config.TestSuffixes, tt.expected.TestSuffixes) {
+				t.Errorf("TestSuffixes = %v, want %v", config.TestSuffixes, tt.expected.TestSuffixes)
+			}
+			if !equalStrings(config.TestPrefixes, tt.expected.TestPrefixes) {
+				t.Errorf("TestPrefixes = %v, want %v", config.TestPrefixes, tt.expected.TestPrefixes)
+			}
+			if config.Framework != tt.expected.Framework {
+				t.Errorf("Framework = %q, want %q", config.Framework, tt.expected.Framework)
+			}
+			if config.TestNaming != tt.expected.TestNaming {
+				t.Errorf("TestNaming = %q, want %q", config.TestNaming, tt.expected.TestNaming)
+			}
+		})
+	}
+}
+
+func TestProject_ConfigOverrides(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "test.gemspec"), "")
+	writeFile(t, filepath.Join(dir, ConfigFileName), `{
+		"src_paths": ["src"],
+		"test_paths": ["checks"],
+		"test_suffixes": ["_check.rb"]
+	}`)
+
+	project := NewProject(dir)
+	if project.ConfigErr != nil {
+		t.Fatalf("unexpected config error: %v", project.ConfigErr)
+	}
+
+	if got := project.SrcPaths(); !equalStrings(got, []string{"src"}) {
+		t.Errorf("SrcPaths() = %v, want [src]", got)
+	}
+	if got := project.TestPaths(); !equalStrings(got, []string{"checks"}) {
+		t.Errorf("TestPaths() = %v, want [checks]", got)
+	}
+	if got := project.Testify("src/user.rb"); got != "src/user_check.rb" {
+		t.Errorf("Testify() = %q, want %q", got, "src/user_check.rb")
+	}
+	if !NewSourceFile("checks/user_check.rb", project).IsTestFile() {
+		t.Errorf("IsTestFile() = false for configured suffix")
+	}
+	if NewSourceFile("checks/user_test.rb", project).IsTestFile() {
+		t.Errorf("IsTestFile() = true for suffix not in config")
+	}
+}
+
+func TestSourceFile_AlternateFileWithConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ConfigFileName), `{
+		"src_paths": ["components"],
+		"test_paths": ["checks"],
+		"test_suffixes": ["_check.rb"]
+	}`)
+	writeFile(t, filepath.Join(dir, "components", "billing", "invoice.rb"), "")
+	writeFile(t, filepath.Join(dir, "checks", "billing", "invoice_check.rb"), "")
+
+	project := NewProject(dir)
+
+	src := NewSourceFile("components/billing/invoice.rb", project)
+	if got, want := src.AlternateFile(), filepath.Join(dir, "checks/billing/invoice_check.rb"); got != want {
+		t.Errorf("AlternateFile() = %q, want %q", got, want)
+	}
+
+	test := NewSourceFile("checks/billing/invoice_check.rb", project)
+	if got, want := test.AlternateFile(), filepath.Join(dir, "components/billing/invoice.rb"); got != want {
+		t.Errorf("AlternateFile() = %q, want %q", got, want)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestProject_PrefixNaming(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    string
+		source    string
+		test      string
+		candidate string
+	}{
+		{
+			name:      "default suffix naming",
+			source:    "app/models/user.rb",
+			test:      "test/models/user_test.rb",
+			candidate: "test/models/test_user.rb",
+		},
+		{
+			name:      "prefix naming",
+			config:    `{"test_naming": "prefix"}`,
+			source:    "app/models/user.rb",
+			test:      "test/models/test_user.rb",
+			candidate: "test/models/tc_user.rb",
+		},
+		{
+			name:      "only prefixes configured",
+			config:    `{"test_prefixes": ["tc_"]}`,
+			source:    "lib/parser.rb",
+			test:      "test/tc_parser.rb",
+			candidate: "test/lib/tc_parser.rb",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, filepath.Join(dir, "test", "test_helper.rb"), "")
+			if tt.config != "" {
+				writeFile(t, filepath.Join(dir, ConfigFileName), tt.config)
+			}
+			project := NewProject(dir)
+			if project.ConfigErr != nil {
+				t.Fatalf("unexpected config error: %v", project.ConfigErr)
+			}
+
+			if got := NewSourceFile(tt.source, project).PreferredTestPath(); got != tt.test {
+				t.Errorf("PreferredTestPath() = %q, want %q", got, tt.test)
+			}
+			for _, test := range []string{tt.test, tt.candidate} {
+				got := NewSourceFile(test, project).Candidates()
+				if !contains(got, tt.source) {
+					t.Errorf("Candidates(%q) = %v, want to include %q", test, got, tt.source)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// constantRegex matches a constant path such as Billing::Invoice
+var constantRegex = regexp.MustCompile(`^(?:[A-Z]\w*::)*[A-Z]\w*$`)
+
+// nonAutoloadedDirs are directories of app/ that Zeitwerk doesn't manage
+var nonAutoloadedDirs = map[string]bool{
+	"assets":     true,
+	"javascript": true,
+	"views":      true,
+}
+
+// DescribedConstant returns the constant a test file is about, read from
+// its first describe block or test case class, e.g. Billing::Invoice for
+// `RSpec.describe Billing::Invoice` or `class Billing::InvoiceTest`.
+// Modules enclosing the block prefix the constant. It returns "" when the
+// subject isn't a constant.
+func DescribedConstant(source string) string {
+	blocks := ScanRuby(source)
+	for _, block := range blocks {
+		var constant string
+		switch {
+		case block.Kind == BlockDescribe && constantRegex.MatchString(block.Name):
+			constant = block.Name
+		case block.Kind == BlockClass && strings.HasSuffix(block.Name, "Test") && block.Name != "Test":
+			constant = strings.TrimSuffix(strings.TrimSuffix(block.Name, "Test"), "::")
+		default:
+			continue
+		}
+
+		var names []string
+		for _, enclosing := range blocks {
+			if enclosing.Line < block.Line && enclosing.Kind == BlockClass && enclosing.Contains(block.Line) {
+				names = append(names, enclosing.Name)
+			}
+		}
+		return strings.Join(append(names, constant), "::")
+	}
+	return ""
+}
+
+// AutoloadRoots returns the directories constants are loaded from,
+// following Zeitwerk's conventions: every directory of app/ holding Ruby
+// code and their concerns, then the other source paths such as lib
+func (p *Project) AutoloadRoots() []string {
+	var roots []string
+	for _, srcPath := range p.SrcPaths() {
+		if srcPath == "" {
+			continue
+		}
+		if srcPath != "app" {
+			roots = append(roots, srcPath)
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(p.Root, "app"))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() || nonAutoloadedDirs[entry.Name()] {
+				continue
+			}
+			root := path.Join("app", entry.Name())
+			roots = append(roots, root)
+			if concerns := path.Join(root, "concerns"); fileExists(filepath.Join(p.Root, concerns)) {
+				roots = append(roots, concerns)
+			}
+		}
+	}
+	return roots
+}
+
+// constantPath returns the path of a source file below the autoload root
+// holding it, without .rb, e.g. billing/invoice for
+// app/models/billing/invoice.rb, or ""
+func (p *Project) constantPath(file string) string {
+	if !strings.HasSuffix(file, ".rb") {
+		return ""
+	}
+	longest := ""
+	for _, root := range p.AutoloadRoots() {
+		if strings.HasPrefix(file, root+"/") && len(root) > len(longest) {
+			longest = root
+		}
+	}
+	if longest == "" {
+		return ""
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(file, longest+"/"), ".rb")
+}
+
+// describedTests returns the test files in the test paths of the project
+// by the underscored constant they describe, reading them once
+func (p *Project) describedTests() map[string][]string {
+	if p.described != nil {
+		return p.described
+	}
+	p.described = map[string][]string{}
+	files, err := p.rubyFilesBelow(p.allTestPaths())
+	if err != nil {
+		return p.described
+	}
+	inflections := p.Inflections()
+	for _, file := range files {
+		if !p.isTestName(file) {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(p.Root, file))
+		if err != nil {
+			continue
+		}
+		if constant := DescribedConstant(string(content)); constant != "" {
+			key := inflections.Underscore(constant)
+			p.described[key] = append(p.described[key], file)
+		}
+	}
+	return p.described
+}
+
+// ConstantAlternates resolves the alternate through the constant a test
+// describes rather than through paths, relative to the project root. A
+// test file maps to the existing files defining its constant in the
+// autoload roots; a source file maps to the tests describing its
+// constant, wherever they are in the test paths. Files in a package are
+// resolved within it.
+func (s *SourceFile) ConstantAlternates() []string {
+	if scoped, dir := s.scoped(); dir != "" {
+		var alternates []string
+		for _, alternate := range scoped.ConstantAlternates() {
+			alternates = append(alternates, path.Join(dir, alternate))
+		}
+		return alternates
+	}
+
+	p := s.Project
+	if !s.IsTestFile() {
+		key := p.constantPath(s.Filename)
+		if key == "" {
+			return nil
+		}
+		tests := append([]string(nil), p.describedTests()[key]...)
+		sort.Strings(tests)
+		return tests
+	}
+
+	content, err := os.ReadFile(filepath.Join(p.Root, s.Filename))
+	if err != nil {
+		return nil
+	}
+	constant := DescribedConstant(string(content))
+	if constant == "" {
+		return nil
+	}
+	key := p.Inflections().Underscore(constant)
+
+	var sources []string
+	for _, root := range p.AutoloadRoots() {
+		if source := path.Join(root, key+".rb"); fileExists(filepath.Join(p.Root, source)) {
+			sources = append(sources, source)
+		}
+	}
+	return uniqueCandidates(sources)
+}
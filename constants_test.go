@@ -0,0 +1,126 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDescribedConstant(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{
+			name:     "rspec describe",
+			source:   "require \"rails_helper\"\n\nRSpec.describe Billing::Invoice, type: :model do\n  describe \"#total\" do\n  end\nend\n",
+			expected: "Billing::Invoice",
+		},
+		{
+			name:     "bare describe",
+			source:   "describe User do\nend\n",
+			expected: "User",
+		},
+		{
+			name:     "string describe",
+			source:   "RSpec.describe \"user signup\" do\nend\n",
+			expected: "",
+		},
+		{
+			name:     "minitest class",
+			source:   "require \"test_helper\"\n\nclass Billing::InvoiceTest < ActiveSupport::TestCase\nend\n",
+			expected: "Billing::Invoice",
+		},
+		{
+			name:     "enclosing modules",
+			source:   "module Billing\n  module Reports\n    class MonthlyTest < Minitest::Test\n    end\n  end\nend\n",
+			expected: "Billing::Reports::Monthly",
+		},
+		{
+			name:     "closed module",
+			source:   "module Helpers\nend\n\nRSpec.describe Invoice do\nend\n",
+			expected: "Invoice",
+		},
+		{
+			name:     "no subject",
+			source:   "class Helper\nend\n",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DescribedConstant(tt.source); got != tt.expected {
+				t.Errorf("DescribedConstant() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// setupConstantsProject creates a Rails app whose specs don't mirror the
+// paths of the files they describe
+func setupConstantsProject(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".rspec"), "")
+	writeFile(t, filepath.Join(dir, "app", "models", "billing", "invoice.rb"), "")
+	writeFile(t, filepath.Join(dir, "app", "models", "concerns", "sluggable.rb"), "")
+	writeFile(t, filepath.Join(dir, "app", "services", "api_client.rb"), "")
+	writeFile(t, filepath.Join(dir, "app", "views", "users", "show.html.erb"), "")
+	writeFile(t, filepath.Join(dir, "config", "initializers", "inflections.rb"), "ActiveSupport::Inflector.inflections do |inflect|\n  inflect.acronym \"API\"\nend\n")
+	writeFile(t, filepath.Join(dir, "spec", "legacy", "invoicing_spec.rb"), "RSpec.describe Billing::Invoice do\nend\n")
+	writeFile(t, filepath.Join(dir, "spec", "concerns", "slugs_spec.rb"), "RSpec.describe Sluggable do\nend\n")
+	writeFile(t, filepath.Join(dir, "spec", "clients", "remote_spec.rb"), "RSpec.describe APIClient do\nend\n")
+	writeFile(t, filepath.Join(dir, "spec", "other", "remote_spec.rb"), "RSpec.describe \"remote calls\" do\nend\n")
+	return dir
+}
+
+func TestProject_AutoloadRoots(t *testing.T) {
+	project := NewProject(setupConstantsProject(t))
+	expected := []string{"app/models", "app/models/concerns", "app/services", "lib"}
+	if got := project.AutoloadRoots(); !equalStrings(got, expected) {
+		t.Errorf("AutoloadRoots() = %v, want %v", got, expected)
+	}
+}
+
+func TestSourceFile_ConstantAlternates(t *testing.T) {
+	dir := setupConstantsProject(t)
+	project := NewProject(dir)
+
+	tests := []struct {
+		file     string
+		expected []string
+	}{
+		{"spec/legacy/invoicing_spec.rb", []string{"app/models/billing/invoice.rb"}},
+		{"app/models/billing/invoice.rb", []string{"spec/legacy/invoicing_spec.rb"}},
+		{"spec/concerns/slugs_spec.rb", []string{"app/models/concerns/sluggable.rb"}},
+		{"app/models/concerns/sluggable.rb", []string{"spec/concerns/slugs_spec.rb"}},
+		{"spec/clients/remote_spec.rb", []string{"app/services/api_client.rb"}},
+		{"app/services/api_client.rb", []string{"spec/clients/remote_spec.rb"}},
+		{"spec/other/remote_spec.rb", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			if got := NewSourceFile(tt.file, project).ConstantAlternates(); !equalStrings(got, tt.expected) {
+				t.Errorf("ConstantAlternates() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSourceFile_AlternateFileByConstant(t *testing.T) {
+	dir := setupConstantsProject(t)
+	project := NewProject(dir)
+
+	if got, want := NewSourceFile("app/models/billing/invoice.rb", project).AlternateFile(), filepath.Join(dir, "spec/legacy/invoicing_spec.rb"); got != want {
+		t.Errorf("AlternateFile() = %q, want %q", got, want)
+	}
+
+	// A mirrored spec still wins over one found through its constant
+	writeFile(t, filepath.Join(dir, "spec", "models", "billing", "invoice_spec.rb"), "")
+	project = NewProject(dir)
+	if got, want := NewSourceFile("app/models/billing/invoice.rb", project).AlternateFile(), filepath.Join(dir, "spec/models/billing/invoice_spec.rb"); got != want {
+		t.Errorf("AlternateFile() = %q, want %q", got, want)
+	}
+}
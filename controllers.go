@@ -0,0 +1,82 @@
+package main
+
+import (
+	"path"
+	"strings"
+)
+
+// Controller test kinds, see Config.ControllerTests
+const (
+	// ControllerTestRequest is an RSpec request spec in spec/requests,
+	// named after the resource: users_spec.rb
+	ControllerTestRequest = "request"
+	// ControllerTestController is a controller test in spec/controllers or
+	// test/controllers: users_controller_spec.rb or users_controller_test.rb
+	ControllerTestController = "controller"
+	// ControllerTestIntegration is a Minitest integration test in
+	// test/integration, named after the resource: users_test.rb
+	ControllerTestIntegration = "integration"
+)
+
+// ControllerTests returns the kinds of controller tests in order of
+// preference, as configured for the project or, for a package, its
+// enclosing project. Kinds that don't apply to the framework are ignored.
+func (p *Project) ControllerTests() []string {
+	for project := p; project != nil; project = project.Parent {
+		if kinds := project.config().ControllerTests; len(kinds) > 0 {
+			return kinds
+		}
+	}
+	if p.IsSpec() {
+		return []string{ControllerTestRequest, ControllerTestController}
+	}
+	return []string{ControllerTestController, ControllerTestIntegration}
+}
+
+// controllerTestCandidates lists the test paths for a controller, in the
+// project's order of preference
+func (s *SourceFile) controllerTestCandidates() []string {
+	rest, ok := replacePathPrefix(s.Filename, "app/controllers", "")
+	if !ok {
+		return nil
+	}
+	name := strings.TrimSuffix(rest, "_controller.rb")
+	anchor := s.Project.TestAnchor()
+	isSpec := s.Project.IsSpec()
+
+	var candidates []string
+	for _, kind := range s.Project.ControllerTests() {
+		switch {
+		case kind == ControllerTestRequest && isSpec:
+			candidates = append(candidates,
+				s.Project.Testify(path.Join(anchor, "requests", name+".rb")),
+				// Request specs are also named after the controller
+				s.Project.Testify(path.Join(anchor, "requests", name+"_controller.rb")))
+		case kind == ControllerTestController:
+			candidates = append(candidates, s.Project.Testify(path.Join(anchor, "controllers", name+"_controller.rb")))
+		case kind == ControllerTestIntegration && !isSpec:
+			candidates = append(candidates, s.Project.Testify(path.Join(anchor, "integration", name+".rb")))
+		}
+	}
+	return candidates
+}
+
+// controllerCandidates lists the controller covered by a request spec or
+// an integration test. Controller tests map back through the generic
+// path mapping.
+func (s *SourceFile) controllerCandidates() []string {
+	source, ok := s.Project.Untestify(s.Filename)
+	if !ok {
+		return nil
+	}
+	dir := "integration"
+	if s.IsRequestSpec() {
+		dir = "requests"
+	}
+	rest, ok := replacePathPrefix(source, path.Join(s.Project.TestAnchor(), dir), "")
+	if !ok {
+		return nil
+	}
+	name := strings.TrimSuffix(strings.TrimSuffix(rest, ".rb"), "_controller")
+	return []string{path.Join("app", "controllers", name+"_controller.rb")}
+}
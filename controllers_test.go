@@ -0,0 +1,113 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSourceFile_ControllerTestCandidates(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []string
+		config   string
+		expected []string
+	}{
+		{
+			name:  "rspec",
+			files: []string{".rspec"},
+			expected: []string{
+				"spec/requests/api/v1/users_spec.rb",
+				"spec/requests/api/v1/users_controller_spec.rb",
+				"spec/controllers/api/v1/users_controller_spec.rb",
+			},
+		},
+		{
+			name:  "minitest",
+			files: []string{"test/test_helper.rb"},
+			expected: []string{
+				"test/controllers/api/v1/users_controller_test.rb",
+				"test/integration/api/v1/users_test.rb",
+			},
+		},
+		{
+			name:   "rspec preferring controller specs",
+			files:  []string{".rspec"},
+			config: `{"controller_tests": ["controller", "request"]}`,
+			expected: []string{
+				"spec/controllers/api/v1/users_controller_spec.rb",
+				"spec/requests/api/v1/users_spec.rb",
+				"spec/requests/api/v1/users_controller_spec.rb",
+			},
+		},
+		{
+			name:   "minitest preferring integration tests",
+			files:  []string{"test/test_helper.rb"},
+			config: `{"controller_tests": ["integration", "request", "controller"]}`,
+			expected: []string{
+				"test/integration/api/v1/users_test.rb",
+				"test/controllers/api/v1/users_controller_test.rb",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, file := range tt.files {
+				writeFile(t, filepath.Join(dir, file), "")
+			}
+			if tt.config != "" {
+				writeFile(t, filepath.Join(dir, ConfigFileName), tt.config)
+			}
+			project := NewProject(dir)
+			if project.ConfigErr != nil {
+				t.Fatalf("unexpected config error: %v", project.ConfigErr)
+			}
+
+			got := NewSourceFile("app/controllers/api/v1/users_controller.rb", project).controllerTestCandidates()
+			if !equalStrings(got, tt.expected) {
+				t.Errorf("controllerTestCandidates() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSourceFile_ControllerAlternates(t *testing.T) {
+	tests := []struct {
+		name   string
+		helper string
+		test   string
+	}{
+		{name: "request spec", helper: ".rspec", test: "spec/requests/api/v1/users_spec.rb"},
+		{name: "request spec named after the controller", helper: ".rspec", test: "spec/requests/api/v1/users_controller_spec.rb"},
+		{name: "controller spec", helper: ".rspec", test: "spec/controllers/api/v1/users_controller_spec.rb"},
+		{name: "minitest controller test", helper: "test/test_helper.rb", test: "test/controllers/api/v1/users_controller_test.rb"},
+		{name: "integration test", helper: "test/test_helper.rb", test: "test/integration/api/v1/users_test.rb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			controller := "app/controllers/api/v1/users_controller.rb"
+			writeFile(t, filepath.Join(dir, tt.helper), "")
+			writeFile(t, filepath.Join(dir, controller), "")
+			writeFile(t, filepath.Join(dir, tt.test), "")
+			project := NewProject(dir)
+
+			if got, want := NewSourceFile(controller, project).AlternateFile(), filepath.Join(dir, tt.test); got != want {
+				t.Errorf("AlternateFile(%q) = %q, want %q", controller, got, want)
+			}
+			if got, want := NewSourceFile(tt.test, project).AlternateFile(), filepath.Join(dir, controller); got != want {
+				t.Errorf("AlternateFile(%q) = %q, want %q", tt.test, got, want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_ControllerTests(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ConfigFileName), `{"controller_tests": ["request", "system"]}`)
+	if _, err := LoadConfig(dir); err == nil {
+		t.Errorf("LoadConfig() expected error for an unknown controller test kind")
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// railsAppDirs are directories below app/<kind>/ that Rails autoloads as
+// roots, so they don't contribute a namespace to the constant name
+var railsAppDirs = []string{"concerns"}
+
+// PreferredTestPath returns the path, relative to the project root, where
+// the test for this source file is expected to live: the first of the
+// test candidates tried when looking up its alternate. Test and factory
+// files have none.
+func (s *SourceFile) PreferredTestPath() string {
+	scoped, dir := s.scoped()
+	if s.IsTestFile() || scoped.IsFactory() {
+		return ""
+	}
+
+	var candidates []string
+	if dir != "" {
+		for _, candidate := range scoped.allTestCandidates() {
+			candidates = append(candidates, path.Join(dir, candidate))
+		}
+	}
+	for _, candidate := range uniqueCandidates(append(candidates, s.allTestCandidates()...)) {
+		// A mapping that doesn't apply leaves the path unchanged
+		if candidate != filepath.Clean(s.Filename) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// allTestCandidates lists the test candidates of the file for each
+// framework of the project
+func (s *SourceFile) allTestCandidates() []string {
+	var candidates []string
+	for _, project := range s.Project.frameworkViews() {
+		candidates = append(candidates, NewSourceFile(s.Filename, project).testCandidates()...)
+	}
+	return candidates
+}
+
+// ConstantName returns the Ruby constant the file is expected to define,
+// derived from its path below the source root (e.g. Api::V1::FoosController)
+func (s *SourceFile) ConstantName() string {
+	if scoped, dir := s.scoped(); dir != "" {
+		return scoped.ConstantName()
+	}
+
+	rest := strings.TrimSuffix(s.Filename, ".rb")
+	for _, srcPath := range s.Project.SrcPaths() {
+		if srcPath != "" && strings.HasPrefix(rest, srcPath+"/") {
+			rest = strings.TrimPrefix(rest, srcPath+"/")
+			if srcPath == "app" {
+				// app/models/user.rb defines User, not Models::User
+				if i := strings.Index(rest, "/"); i >= 0 {
+					rest = rest[i+1:]
+				}
+			}
+			break
+		}
+	}
+
+	inflections := s.Project.Inflections()
+	var parts []string
+	for _, segment := range strings.Split(rest, "/") {
+		if segment == "" || contains(railsAppDirs, segment) {
+			continue
+		}
+		parts = append(parts, inflections.Camelize(segment))
+	}
+	return strings.Join(parts, "::")
+}
+
+// TestSkeleton returns the initial content of a new test for this file
+func (s *SourceFile) TestSkeleton() string {
+	if scoped, dir := s.scoped(); dir != "" {
+		return scoped.TestSkeleton()
+	}
+
+	constant := s.ConstantName()
+
+	if s.Project.IsSpec() {
+		helper := "spec_helper"
+		if fileExists(filepath.Join(s.Project.Root, "spec", "rails_helper.rb")) {
+			helper = "rails_helper"
+		}
+		return fmt.Sprintf("require \"%s\"\n\nRSpec.describe %s do\nend\n", helper, constant)
+	}
+
+	return fmt.Sprintf("require \"test_helper\"\n\nclass %sTest < Minitest::Test\nend\n", constant)
+}
+
+// CreateTestFile writes a skeleton test at the preferred test path,
+// creating intermediate directories. An existing file is never
+// overwritten; its path is returned as is.
+func (s *SourceFile) CreateTestFile() (string, error) {
+	if s.IsTestFile() {
+		return "", fmt.Errorf("%s is already a test file", s.Filename)
+	}
+	if scoped, _ := s.scoped(); scoped.IsFactory() {
+		return "", fmt.Errorf("%s is a factory file, it has no test", s.Filename)
+	}
+
+	path := s.PreferredTestPath()
+	if path == "" {
+		return "", fmt.Errorf("no test path mapping for %s", s.Filename)
+	}
+
+	target := filepath.Join(s.Project.Root, path)
+	if fileExists(target) {
+		return target, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return "", err
+	}
+
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return target, nil
+		}
+		return "", err
+	}
+	if _, err := f.WriteString(s.TestSkeleton()); err != nil {
+		f.Close()
+		return "", err
+	}
+	return target, f.Close()
+}
+
+// contains reports whether list contains value
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSourceFile_PreferredTestPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(dir string)
+		filename string
+		expected string
+	}{
+		{
+			name:     "rails model with rspec",
+			setup:    func(dir string) { writeFile(t, filepath.Join(dir, ".rspec"), "") },
+			filename: "app/models/user.rb",
+			expected: "spec/models/user_spec.rb",
+		},
+		{
+			name:     "rails lib file with minitest",
+			setup:    func(dir string) {},
+			filename: "lib/tasks/importer.rb",
+			expected: "test/tasks/importer_test.rb",
+		},
+		{
+			name:     "controller with rspec",
+			setup:    func(dir string) { writeFile(t, filepath.Join(dir, ".rspec"), "") },
+			filename: "app/controllers/api/v1/foos_controller.rb",
+			expected: "spec/requests/api/v1/foos_spec.rb",
+		},
+		{
+			name: "gem file",
+			setup: func(dir string) {
+				writeFile(t, filepath.Join(dir, "my_gem.gemspec"), "")
+				writeFile(t, filepath.Join(dir, ".rspec"), "")
+			},
+			filename: "lib/my_gem/user.rb",
+			expected: "spec/my_gem/user_spec.rb",
+		},
+		{
+			name: "projection takes precedence",
+			setup: func(dir string) {
+				writeFile(t, filepath.Join(dir, ProjectionsFileName), `{"app/*.rb": {"alternate": "spec/unit/{}_spec.rb"}}`)
+			},
+			filename: "app/models/user.rb",
+			expected: "spec/unit/models/user_spec.rb",
+		},
+		{
+			name:     "factory file has no test path",
+			setup:    func(dir string) { writeFile(t, filepath.Join(dir, ".rspec"), "") },
+			filename: "spec/factories/users.rb",
+			expected: "",
+		},
+		{
+			name:     "test file has no test path",
+			setup:    func(dir string) {},
+			filename: "test/models/user_test.rb",
+			expected: "",
+		},
+		{
+			name:     "file outside source roots",
+			setup:    func(dir string) {},
+			filename: "config/routes.rb",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tt.setup(dir)
+
+			sourceFile := NewSourceFile(tt.filename, NewProject(dir))
+			if got := sourceFile.PreferredTestPath(); got != tt.expected {
+				t.Errorf("PreferredTestPath() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSourceFile_ConstantName(t *testing.T) {
+	tests := []struct {
+		name     string
+		isGem    bool
+		acronyms string
+		filename string
+		expected string
+	}{
+		{name: "rails model", filename: "app/models/user.rb", expected: "User"},
+		{name: "namespaced controller", filename: "app/controllers/api/v1/foos_controller.rb", expected: "Api::V1::FoosController"},
+		{name: "model concern", filename: "app/models/concerns/sluggable.rb", expected: "Sluggable"},
+		{name: "rails lib file", filename: "lib/billing/invoice_parser.rb", expected: "Billing::InvoiceParser"},
+		{name: "gem file", isGem: true, filename: "lib/my_gem/user.rb", expected: "MyGem::User"},
+		{
+			name:     "initializer acronyms",
+			acronyms: "ActiveSupport::Inflector.inflections(:en) do |inflect|\n  inflect.acronym \"API\"\nend\n",
+			filename: "app/controllers/api/v1/foos_controller.rb",
+			expected: "API::V1::FoosController",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.isGem {
+				writeFile(t, filepath.Join(dir, "my_gem.gemspec"), "")
+			}
+			if tt.acronyms != "" {
+				writeFile(t, filepath.Join(dir, "config", "initializers", "inflections.rb"), tt.acronyms)
+			}
+
+			sourceFile := NewSourceFile(tt.filename, NewProject(dir))
+			if got := sourceFile.ConstantName(); got != tt.expected {
+				t.Errorf("ConstantName() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSourceFile_TestSkeleton(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(dir string)
+		expected string
+	}{
+		{
+			name:     "rspec",
+			setup:    func(dir string) { writeFile(t, filepath.Join(dir, ".rspec"), "") },
+			expected: "require \"spec_helper\"\n\nRSpec.describe Billing::Invoice do\nend\n",
+		},
+		{
+			name: "rspec rails",
+			setup: func(dir string) {
+				writeFile(t, filepath.Join(dir, ".rspec"), "")
+				writeFile(t, filepath.Join(dir, "spec", "rails_helper.rb"), "")
+			},
+			expected: "require \"rails_helper\"\n\nRSpec.describe Billing::Invoice do\nend\n",
+		},
+		{
+			name:     "minitest",
+			setup:    func(dir string) {},
+			expected: "require \"test_helper\"\n\nclass Billing::InvoiceTest < Minitest::Test\nend\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tt.setup(dir)
+
+			sourceFile := NewSourceFile("app/models/billing/invoice.rb", NewProject(dir))
+			if got := sourceFile.TestSkeleton(); got != tt.expected {
+				t.Errorf("TestSkeleton() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSourceFile_CreateTestFile(t *testing.T) {
+	t.Run("creates directories and skeleton", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, ".rspec"), "")
+		writeFile(t, filepath.Join(dir, "app", "models", "user.rb"), "")
+
+		sourceFile := NewSourceFile("app/models/user.rb", NewProject(dir))
+		got, err := sourceFile.CreateTestFile()
+		if err != nil {
+			t.Fatalf("CreateTestFile() unexpected error: %v", err)
+		}
+
+		expected := filepath.Join(dir, "spec", "models", "user_spec.rb")
+		if got != expected {
+			t.Errorf("CreateTestFile() = %q, want %q", got, expected)
+		}
+		content, err := os.ReadFile(expected)
+		if err != nil {
+			t.Fatalf("test file not created: %v", err)
+		}
+		if string(content) != sourceFile.TestSkeleton() {
+			t.Errorf("test file content = %q, want skeleton", content)
+		}
+		if alternate := sourceFile.AlternateFile(); alternate != expected {
+			t.Errorf("AlternateFile() = %q after create, want %q", alternate, expected)
+		}
+	})
+
+	t.Run("never overwrites an existing file", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, ".rspec"), "")
+		existing := filepath.Join(dir, "spec", "models", "user_spec.rb")
+		writeFile(t, existing, "# keep me\n")
+
+		sourceFile := NewSourceFile("app/models/user.rb", NewProject(dir))
+		got, err := sourceFile.CreateTestFile()
+		if err != nil {
+			t.Fatalf("CreateTestFile() unexpected error: %v", err)
+		}
+		if got != existing {
+			t.Errorf("CreateTestFile() = %q, want %q", got, existing)
+		}
+		content, _ := os.ReadFile(existing)
+		if string(content) != "# keep me\n" {
+			t.Errorf("existing file was overwritten: %q", content)
+		}
+	})
+
+	t.Run("refuses test files", func(t *testing.T) {
+		dir := t.TempDir()
+		sourceFile := NewSourceFile("test/models/user_test.rb", NewProject(dir))
+		if _, err := sourceFile.CreateTestFile(); err == nil {
+			t.Errorf("CreateTestFile() expected error for a test file")
+		}
+	})
+	t.Run("refuses factory files", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, ".rspec"), "")
+		writeFile(t, filepath.Join(dir, "spec", "factories", "users.rb"), "")
+
+		sourceFile := NewSourceFile("spec/factories/users.rb", NewProject(dir))
+		if _, err := sourceFile.CreateTestFile(); err == nil {
+			t.Errorf("CreateTestFile() expected error for a factory file")
+		}
+		if fileExists(filepath.Join(dir, "app", "models", "user.rb")) {
+			t.Errorf("CreateTestFile() wrote to the model of a factory")
+		}
+	})
+}
+
+func TestCLI_RunLookupCreateFactory(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".rspec"), "")
+	writeFile(t, filepath.Join(dir, "spec", "factories", "users.rb"), "")
+
+	cli := &CLI{Command: "lookup", Root: dir, Path: "spec/factories/users.rb", Create: true, Print: true, Stdout: &bytes.Buffer{}}
+	if err := cli.Run(); err == nil {
+		t.Error("lookup --create expected an error for a factory without a model")
+	}
+	if fileExists(filepath.Join(dir, "app", "models", "user.rb")) {
+		t.Error("lookup --create wrote to the model of a factory")
+	}
+}
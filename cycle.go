@@ -0,0 +1,112 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// CycleState remembers the ring of related files being stepped through,
+// so that repeated cycles from the file last opened continue around the
+// same ring
+type CycleState struct {
+	// Ring lists the related files in cycling order, relative to the root
+	Ring []string `json:"ring"`
+	// Last is the file the previous cycle opened
+	Last string `json:"last"`
+}
+
+// cycleStatePath returns the file keeping the cycle state of a project
+// within the cache directory
+func cycleStatePath(cacheDir, root string) string {
+	sum := sha256.Sum256([]byte(root))
+	return filepath.Join(cacheDir, "go-zed-test-toggle", hex.EncodeToString(sum[:8])+".json")
+}
+
+// LoadCycleState reads the cycle state. A missing or unreadable state is
+// not an error: cycling starts over.
+func LoadCycleState(path string) *CycleState {
+	state := &CycleState{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return state
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return &CycleState{}
+	}
+	return state
+}
+
+// Save writes the cycle state, creating the cache directory if needed
+func (c *CycleState) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// RelatedFiles returns the existing files related to this one, relative
+// to the project root: the source file first, then every existing test
+// file in candidate order, then the factory file of a model, then the
+// views, controller and helper related to a Rails view, controller or
+// helper. The order doesn't depend on
+// which of the source and its tests it is computed from.
+func (s *SourceFile) RelatedFiles() []string {
+	sourceFile := s.source()
+	if sourceFile == s && s.IsTestFile() {
+		return []string{s.Filename}
+	}
+
+	related := []string{sourceFile.Filename}
+	for _, candidate := range sourceFile.Candidates() {
+		if fileExists(filepath.Join(s.Project.Root, candidate)) {
+			related = append(related, candidate)
+		}
+	}
+	// Factories, then views, controllers and helpers of the same resource
+	if factory := sourceFile.FactoryFile(); factory != "" && !sourceFile.IsFactory() {
+		rel, _ := filepath.Rel(s.Project.Root, factory)
+		related = append(related, filepath.ToSlash(rel))
+	}
+	related = append(related, sourceFile.relatedSources()...)
+	// A test that resolves to a source which doesn't map back still
+	// belongs to the ring
+	related = append(related, s.Filename)
+	return uniqueCandidates(related)
+}
+
+// NextRelated returns the related file following this one and the
+// updated state. The saved ring is reused when this file is the one the
+// previous cycle opened; otherwise a new ring is built from this file. It
+// returns "" when the file has no related files.
+func (s *SourceFile) NextRelated(state *CycleState) (string, *CycleState) {
+	var ring []string
+	if state != nil && state.Last == s.Filename && contains(state.Ring, s.Filename) {
+		// Drop files deleted since the ring was built
+		for _, file := range state.Ring {
+			if file == s.Filename || fileExists(filepath.Join(s.Project.Root, file)) {
+				ring = append(ring, file)
+			}
+		}
+	} else {
+		ring = s.RelatedFiles()
+	}
+	if len(ring) < 2 {
+		return "", state
+	}
+
+	next := ring[0]
+	for i, file := range ring {
+		if file == s.Filename {
+			next = ring[(i+1)%len(ring)]
+			break
+		}
+	}
+	return next, &CycleState{Ring: ring, Last: next}
+}
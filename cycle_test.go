@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupMixedProject creates a model with both a spec and a Minitest test
+func setupMixedProject(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "spec", "spec_helper.rb"), "")
+	writeFile(t, filepath.Join(dir, "test", "test_helper.rb"), "")
+	writeFile(t, filepath.Join(dir, "app", "models", "user.rb"), "")
+	writeFile(t, filepath.Join(dir, "spec", "models", "user_spec.rb"), "")
+	writeFile(t, filepath.Join(dir, "test", "models", "user_test.rb"), "")
+	return dir
+}
+
+func TestSourceFile_RelatedFiles(t *testing.T) {
+	dir := setupMixedProject(t)
+	project := NewProject(dir)
+
+	expected := []string{"app/models/user.rb", "spec/models/user_spec.rb", "test/models/user_test.rb"}
+	for _, file := range expected {
+		if got := NewSourceFile(file, project).RelatedFiles(); !equalStrings(got, expected) {
+			t.Errorf("RelatedFiles(%q) = %v, want %v", file, got, expected)
+		}
+	}
+
+	writeFile(t, filepath.Join(dir, "lib", "orphan.rb"), "")
+	if got := NewSourceFile("lib/orphan.rb", project).RelatedFiles(); !equalStrings(got, []string{"lib/orphan.rb"}) {
+		t.Errorf("RelatedFiles() = %v, want only the file itself", got)
+	}
+}
+
+func TestSourceFile_NextRelated(t *testing.T) {
+	dir := setupMixedProject(t)
+	project := NewProject(dir)
+
+	state := &CycleState{}
+	file := "app/models/user.rb"
+	var visited []string
+	for i := 0; i < 3; i++ {
+		file, state = NewSourceFile(file, project).NextRelated(state)
+		visited = append(visited, file)
+	}
+	expected := []string{"spec/models/user_spec.rb", "test/models/user_test.rb", "app/models/user.rb"}
+	if !equalStrings(visited, expected) {
+		t.Errorf("NextRelated() visited %v, want %v", visited, expected)
+	}
+
+	// Starting from another file builds a new ring
+	next, _ := NewSourceFile("test/models/user_test.rb", project).NextRelated(&CycleState{Ring: []string{"a.rb", "b.rb"}, Last: "a.rb"})
+	if next != "app/models/user.rb" {
+		t.Errorf("NextRelated() = %q, want %q", next, "app/models/user.rb")
+	}
+
+	// Deleted files are skipped
+	if err := os.Remove(filepath.Join(dir, "spec", "models", "user_spec.rb")); err != nil {
+		t.Fatalf("remove failed: %v", err)
+	}
+	state = &CycleState{Ring: expected, Last: "test/models/user_test.rb"}
+	next, _ = NewSourceFile("test/models/user_test.rb", project).NextRelated(state)
+	if next != "app/models/user.rb" {
+		t.Errorf("NextRelated() = %q, want %q", next, "app/models/user.rb")
+	}
+}
+
+func TestSourceFile_NextRelatedNone(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "lib", "user.rb"), "")
+
+	if next, _ := NewSourceFile("lib/user.rb", NewProject(dir)).NextRelated(&CycleState{}); next != "" {
+		t.Errorf("NextRelated() = %q, want empty", next)
+	}
+}
+
+func TestCycleState_SaveLoad(t *testing.T) {
+	path := cycleStatePath(t.TempDir(), "/path/to/project")
+	if got := LoadCycleState(path); len(got.Ring) != 0 || got.Last != "" {
+		t.Errorf("LoadCycleState() = %+v for a missing file, want empty", got)
+	}
+
+	state := &CycleState{Ring: []string{"a.rb", "spec/a_spec.rb"}, Last: "spec/a_spec.rb"}
+	if err := state.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	got := LoadCycleState(path)
+	if !equalStrings(got.Ring, state.Ring) || got.Last != state.Last {
+		t.Errorf("LoadCycleState() = %+v, want %+v", got, state)
+	}
+
+	if other := cycleStatePath(filepath.Dir(filepath.Dir(path)), "/path/to/other"); other == path {
+		t.Errorf("cycleStatePath() is the same for different projects")
+	}
+}
+
+func TestCLI_RunCycle(t *testing.T) {
+	dir := setupMixedProject(t)
+	cacheDir := t.TempDir()
+
+	path := "app/models/user.rb"
+	var visited []string
+	for i := 0; i < 3; i++ {
+		var stdout bytes.Buffer
+		cli := &CLI{Command: "cycle", Root: dir, Path: path, Print: true, CacheDir: cacheDir, Stdout: &stdout}
+		if err := cli.Run(); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		next := strings.TrimSpace(stdout.String())
+		visited = append(visited, next)
+		path = next
+	}
+
+	expected := []string{
+		filepath.Join(dir, "spec", "models", "user_spec.rb"),
+		filepath.Join(dir, "test", "models", "user_test.rb"),
+		filepath.Join(dir, "app", "models", "user.rb"),
+	}
+	if !equalStrings(visited, expected) {
+		t.Errorf("cycle visited %v, want %v", visited, expected)
+	}
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// skippedDirs are directories never searched for Ruby files
+var skippedDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	"vendor":       true,
+	"tmp":          true,
+	"log":          true,
+	"coverage":     true,
+}
+
+// Report is the result of auditing a project's source/test mapping.
+// All paths are relative to the project root.
+type Report struct {
+	// Checked is the number of source and test files audited
+	Checked int `json:"checked"`
+	// Untested lists source files with no test
+	Untested []string `json:"untested"`
+	// Orphaned lists test files whose source can't be resolved
+	Orphaned []string `json:"orphaned"`
+	// Broken lists pairs where toggling back doesn't return to the file
+	Broken []BrokenPair `json:"broken"`
+	// Ambiguous lists files with more than one existing alternate
+	Ambiguous []AmbiguousFile `json:"ambiguous"`
+}
+
+// BrokenPair is a file whose alternate toggles back to a different file
+type BrokenPair struct {
+	File      string `json:"file"`
+	Alternate string `json:"alternate"`
+	Back      string `json:"back"`
+}
+
+// AmbiguousFile is a file with several existing alternates
+type AmbiguousFile struct {
+	File       string   `json:"file"`
+	Candidates []string `json:"candidates"`
+}
+
+// Thresholds caps the number of problems of each kind a Report may have.
+// A negative value disables the check.
+type Thresholds struct {
+	Untested  int
+	Orphaned  int
+	Broken    int
+	Ambiguous int
+}
+
+// Doctor walks the project and audits every Ruby file in the source and
+// test paths
+func (p *Project) Doctor() (*Report, error) {
+	files, err := p.rubyFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		Untested:  []string{},
+		Orphaned:  []string{},
+		Broken:    []BrokenPair{},
+		Ambiguous: []AmbiguousFile{},
+	}
+	for _, file := range files {
+		sourceFile := NewSourceFile(file, p)
+		isTest := sourceFile.IsTestFile()
+		if !isTest && !p.inSrcPaths(file) {
+			continue
+		}
+		report.Checked++
+
+		var existing []string
+		for _, candidate := range sourceFile.Candidates() {
+			if fileExists(filepath.Join(p.Root, candidate)) {
+				existing = append(existing, candidate)
+			}
+		}
+		if len(existing) == 0 {
+			existing = sourceFile.ConstantAlternates()
+		}
+
+		if len(existing) == 0 {
+			if isTest {
+				report.Orphaned = append(report.Orphaned, file)
+			} else {
+				report.Untested = append(report.Untested, file)
+			}
+			continue
+		}
+		if len(existing) > 1 {
+			report.Ambiguous = append(report.Ambiguous, AmbiguousFile{File: file, Candidates: existing})
+		}
+
+		back := NewSourceFile(existing[0], p).AlternateFile()
+		if back != filepath.Join(p.Root, file) {
+			rel := ""
+			if back != "" {
+				rel, _ = filepath.Rel(p.Root, back)
+			}
+			report.Broken = append(report.Broken, BrokenPair{File: file, Alternate: existing[0], Back: rel})
+		}
+	}
+	return report, nil
+}
+
+// rubyFiles returns every .rb file in the project, relative to its root
+func (p *Project) rubyFiles() ([]string, error) {
+	files, err := p.walkRubyFiles(p.Root)
+	sort.Strings(files)
+	return files, err
+}
+
+// rubyFilesBelow returns the .rb files below the directories, relative to
+// the project root. The root itself, as "", only contributes its top-level
+// files, like a source path. Missing directories are skipped.
+func (p *Project) rubyFilesBelow(dirs []string) ([]string, error) {
+	var files []string
+	for _, dir := range dirs {
+		if dir == "" {
+			entries, err := os.ReadDir(p.Root)
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range entries {
+				if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".rb") {
+					files = append(files, entry.Name())
+				}
+			}
+			continue
+		}
+		found, err := p.walkRubyFiles(filepath.Join(p.Root, filepath.FromSlash(dir)))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		files = append(files, found...)
+	}
+	files = uniqueCandidates(files)
+	sort.Strings(files)
+	return files, nil
+}
+
+// walkRubyFiles returns the .rb files below a directory, relative to the
+// project root
+func (p *Project) walkRubyFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && skippedDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(path, ".rb") {
+			rel, err := filepath.Rel(p.Root, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	return files, err
+}
+
+// inSrcPaths reports whether the file is below one of the source paths.
+// The project root as a source path only covers files at the top level.
+func (p *Project) inSrcPaths(file string) bool {
+	if pkg, dir := p.Package(file); pkg != nil && pkg.inSrcPaths(strings.TrimPrefix(file, dir+"/")) {
+		return true
+	}
+	for _, srcPath := range p.SrcPaths() {
+		if srcPath == "" {
+			if !strings.Contains(file, "/") {
+				return true
+			}
+			continue
+		}
+		if strings.HasPrefix(file, srcPath+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Exceeds returns the problems that go over the thresholds
+func (r *Report) Exceeds(t Thresholds) []string {
+	var exceeded []string
+	check := func(name string, count, max int) {
+		if max >= 0 && count > max {
+			exceeded = append(exceeded, fmt.Sprintf("%d %s (max %d)", count, name, max))
+		}
+	}
+	check("untested source files", len(r.Untested), t.Untested)
+	check("orphaned test files", len(r.Orphaned), t.Orphaned)
+	check("broken round trips", len(r.Broken), t.Broken)
+	check("ambiguous files", len(r.Ambiguous), t.Ambiguous)
+	return exceeded
+}
+
+// WriteTable writes the report as a human readable table
+func (r *Report) WriteTable(w io.Writer) {
+	for _, file := range r.Untested {
+		fmt.Fprintf(w, "%-10s %s\n", "untested", file)
+	}
+	for _, file := range r.Orphaned {
+		fmt.Fprintf(w, "%-10s %s\n", "orphaned", file)
+	}
+	for _, pair := range r.Broken {
+		back := pair.Back
+		if back == "" {
+			back = "(none)"
+		}
+		fmt.Fprintf(w, "%-10s %s -> %s -> %s\n", "broken", pair.File, pair.Alternate, back)
+	}
+	for _, file := range r.Ambiguous {
+		fmt.Fprintf(w, "%-10s %s -> %s\n", "ambiguous", file.File, strings.Join(file.Candidates, ", "))
+	}
+
+	fmt.Fprintf(w, "\n%d files checked: %d untested, %d orphaned, %d broken, %d ambiguous\n",
+		r.Checked, len(r.Untested), len(r.Orphaned), len(r.Broken), len(r.Ambiguous))
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func setupDoctorProject(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, file := range []string{
+		".rspec",
+		"spec/spec_helper.rb",
+		"app/models/user.rb",
+		"spec/models/user_spec.rb",
+		"app/models/post.rb",
+		"spec/models/comment_spec.rb",
+		"app/models/tag.rb",
+		"spec/models/tag_spec.rb",
+		"spec/lib/models/tag_spec.rb",
+		"vendor/bundle/gems/foo.rb",
+		"config/application.rb",
+	} {
+		writeFile(t, filepath.Join(dir, file), "")
+	}
+	return dir
+}
+
+func TestProject_Doctor(t *testing.T) {
+	dir := setupDoctorProject(t)
+
+	report, err := NewProject(dir).Doctor()
+	if err != nil {
+		t.Fatalf("Doctor() unexpected error: %v", err)
+	}
+
+	if report.Checked != 7 {
+		t.Errorf("Checked = %d, want 7", report.Checked)
+	}
+	if !equalStrings(report.Untested, []string{"app/models/post.rb"}) {
+		t.Errorf("Untested = %v", report.Untested)
+	}
+	if !equalStrings(report.Orphaned, []string{"spec/models/comment_spec.rb"}) {
+		t.Errorf("Orphaned = %v", report.Orphaned)
+	}
+
+	expectedBroken := BrokenPair{File: "spec/lib/models/tag_spec.rb", Alternate: "app/models/tag.rb", Back: "spec/models/tag_spec.rb"}
+	if len(report.Broken) != 1 || report.Broken[0] != expectedBroken {
+		t.Errorf("Broken = %+v, want [%+v]", report.Broken, expectedBroken)
+	}
+
+	if len(report.Ambiguous) != 1 || report.Ambiguous[0].File != "app/models/tag.rb" ||
+		!equalStrings(report.Ambiguous[0].Candidates, []string{"spec/models/tag_spec.rb", "spec/lib/models/tag_spec.rb"}) {
+		t.Errorf("Ambiguous = %+v", report.Ambiguous)
+	}
+}
+
+func TestReport_Exceeds(t *testing.T) {
+	report := &Report{
+		Untested: []string{"a.rb", "b.rb"},
+		Orphaned: []string{"c_spec.rb"},
+	}
+
+	tests := []struct {
+		name       string
+		thresholds Thresholds
+		expected   int
+	}{
+		{name: "disabled", thresholds: Thresholds{Untested: -1, Orphaned: -1, Broken: -1, Ambiguous: -1}, expected: 0},
+		{name: "within limits", thresholds: Thresholds{Untested: 2, Orphaned: 1, Broken: 0, Ambiguous: 0}, expected: 0},
+		{name: "exceeded", thresholds: Thresholds{Untested: 1, Orphaned: 0, Broken: 0, Ambiguous: 0}, expected: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := report.Exceeds(tt.thresholds); len(got) != tt.expected {
+				t.Errorf("Exceeds() = %v, want %d problems", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReport_WriteTable(t *testing.T) {
+	report := &Report{
+		Checked:   4,
+		Untested:  []string{"app/models/post.rb"},
+		Broken:    []BrokenPair{{File: "spec/lib/tag_spec.rb", Alternate: "app/tag.rb"}},
+		Ambiguous: []AmbiguousFile{{File: "app/tag.rb", Candidates: []string{"spec/tag_spec.rb", "spec/lib/tag_spec.rb"}}},
+	}
+
+	var out bytes.Buffer
+	report.WriteTable(&out)
+	output := out.String()
+
+	for _, line := range []string{
+		"untested   app/models/post.rb\n",
+		"broken     spec/lib/tag_spec.rb -> app/tag.rb -> (none)\n",
+		"ambiguous  app/tag.rb -> spec/tag_spec.rb, spec/lib/tag_spec.rb\n",
+		"4 files checked: 1 untested, 0 orphaned, 1 broken, 1 ambiguous\n",
+	} {
+		if !strings.Contains(output, line) {
+			t.Errorf("WriteTable() output missing %q:\n%s", line, output)
+		}
+	}
+}
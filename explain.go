@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Explain writes a trace of how the alternate file is resolved: the
+// detected project kind and the clues behind it, the path mapping in use,
+// and every candidate tried along with whether it exists
+func (s *SourceFile) Explain(w io.Writer) {
+	p := s.Project
+
+	fmt.Fprintf(w, "Project root:  %s\n", p.Root)
+	fmt.Fprintf(w, "Project type:  %s\n", p.Kind())
+	fmt.Fprintf(w, "Gem:           %s\n", describeClue(p.Root, p.GemClue()))
+	fmt.Fprintf(w, "RSpec:         %s\n", describeClue(p.Root, p.SpecClue()))
+	var frameworks []string
+	for _, framework := range p.Frameworks() {
+		frameworks = append(frameworks, string(framework))
+	}
+	fmt.Fprintf(w, "Framework:     %s\n", strings.Join(frameworks, ", "))
+	fmt.Fprintf(w, "Config:        %s\n", describeFile(p.Root, ConfigFileName))
+	fmt.Fprintf(w, "Projections:   %s\n", describeFile(p.Root, ProjectionsFileName))
+	fmt.Fprintf(w, "Source paths:  %s\n", formatPaths(p.SrcPaths()))
+	fmt.Fprintf(w, "Test paths:    %s\n", formatPaths(p.TestPaths()))
+
+	var regexes []string
+	for _, regex := range p.TestRegexes() {
+		regexes = append(regexes, regex.String())
+	}
+	fmt.Fprintf(w, "Test regexes:  %s\n", strings.Join(regexes, ", "))
+	fmt.Fprintln(w)
+
+	role := "source file"
+	if s.IsTestFile() {
+		role = "test file"
+	}
+	fmt.Fprintf(w, "File:          %s (%s)\n", s.Filename, role)
+	if scoped, dir := s.scoped(); dir != "" {
+		fmt.Fprintf(w, "Package:       %s (%s, source paths: %s, test paths: %s)\n", dir,
+			scoped.Project.Framework(), formatPaths(scoped.Project.SrcPaths()), formatPaths(scoped.Project.TestPaths()))
+	}
+	fmt.Fprintln(w, "Candidates:")
+
+	alternate := ""
+	candidates := s.Candidates()
+	if len(candidates) == 0 {
+		fmt.Fprintln(w, "  (none)")
+	}
+	for _, candidate := range candidates {
+		mark := "missing"
+		if fileExists(filepath.Join(p.Root, candidate)) {
+			mark = "found"
+			if alternate == "" {
+				alternate = candidate
+			}
+		}
+		fmt.Fprintf(w, "  %-8s %s\n", mark, candidate)
+	}
+
+	if alternate == "" {
+		alternates := s.ConstantAlternates()
+		fmt.Fprintln(w, "Constant matches:")
+		if len(alternates) == 0 {
+			fmt.Fprintln(w, "  (none)")
+		}
+		for _, match := range alternates {
+			fmt.Fprintf(w, "  found    %s\n", match)
+		}
+		if len(alternates) > 0 {
+			alternate = alternates[0] + " (constant)"
+		}
+	}
+	if alternate == "" {
+		matches := s.FuzzyMatches()
+		fmt.Fprintln(w, "Fuzzy matches:")
+		if len(matches) == 0 {
+			fmt.Fprintln(w, "  (none)")
+		}
+		for _, match := range matches {
+			fmt.Fprintf(w, "  %.2f     %s\n", match.Score, match.Path)
+		}
+		alternate = "(none)"
+		if match := p.ConfidentMatch(matches); match != "" {
+			alternate = match + " (fuzzy)"
+		}
+	}
+	fmt.Fprintf(w, "Alternate:     %s\n", alternate)
+}
+
+// describeClue reports whether a detection clue matched, and which file
+func describeClue(root, clue string) string {
+	if clue == "" {
+		return "no"
+	}
+	if rel, err := filepath.Rel(root, clue); err == nil {
+		clue = rel
+	}
+	return fmt.Sprintf("yes (%s)", clue)
+}
+
+// describeFile reports whether an optional project file is present
+func describeFile(root, name string) string {
+	if fileExists(filepath.Join(root, name)) {
+		return name
+	}
+	return "none"
+}
+
+// formatPaths formats a list of project paths, showing "" as the root
+func formatPaths(paths []string) string {
+	formatted := make([]string, len(paths))
+	for i, path := range paths {
+		if path == "" {
+			path = "(root)"
+		}
+		formatted[i] = path
+	}
+	return strings.Join(formatted, ", ")
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProject_Clues(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "my_gem.gemspec"), "")
+	writeFile(t, filepath.Join(dir, ".rspec"), "")
+
+	project := NewProject(dir)
+	if got, want := project.GemClue(), filepath.Join(dir, "my_gem.gemspec"); got != want {
+		t.Errorf("GemClue() = %q, want %q", got, want)
+	}
+	if got, want := project.SpecClue(), filepath.Join(dir, ".rspec"); got != want {
+		t.Errorf("SpecClue() = %q, want %q", got, want)
+	}
+
+	empty := NewProject(t.TempDir())
+	if got := empty.GemClue(); got != "" {
+		t.Errorf("GemClue() = %q, want empty", got)
+	}
+	if got := empty.SpecClue(); got != "" {
+		t.Errorf("SpecClue() = %q, want empty", got)
+	}
+}
+
+func TestSourceFile_Explain(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".rspec"), "")
+	writeFile(t, filepath.Join(dir, "app", "models", "user.rb"), "")
+	writeFile(t, filepath.Join(dir, "spec", "models", "user_spec.rb"), "")
+
+	var out bytes.Buffer
+	NewSourceFile("app/models/user.rb", NewProject(dir)).Explain(&out)
+	output := out.String()
+
+	expected := []string{
+		"Gem:           no\n",
+		"RSpec:         yes (.rspec)\n",
+		"Framework:     rspec\n",
+		"Source paths:  app, lib\n",
+		"Test paths:    spec, spec/lib\n",
+		"File:          app/models/user.rb (source file)\n",
+		"  found    spec/models/user_spec.rb\n",
+		"  missing  spec/lib/models/user_spec.rb\n",
+		"Alternate:     spec/models/user_spec.rb\n",
+	}
+	for _, line := range expected {
+		if !strings.Contains(output, line) {
+			t.Errorf("Explain() output missing %q:\n%s", line, output)
+		}
+	}
+}
+
+func TestSourceFile_ExplainWithoutAlternate(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "my_gem.gemspec"), "")
+
+	var out bytes.Buffer
+	NewSourceFile("lib/user.rb", NewProject(dir)).Explain(&out)
+	output := out.String()
+
+	for _, line := range []string{"Gem:           yes (my_gem.gemspec)\n", "Source paths:  lib, (root)\n", "Alternate:     (none)\n"} {
+		if !strings.Contains(output, line) {
+			t.Errorf("Explain() output missing %q:\n%s", line, output)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/stephen/go-zed-test-toggle/inflect"
+)
+
+// Inflections returns the inflection rules used to name files and
+// constants: the Rails defaults with the declarations of the project's
+// config/initializers/inflections.rb, or of the enclosing project's for a
+// package without one. Declarations that can't be translated are ignored.
+func (p *Project) Inflections() *inflect.Inflections {
+	if p.inflections != nil {
+		return p.inflections
+	}
+	initializer := filepath.Join(p.Root, filepath.FromSlash(inflect.InitializerPath))
+	if p.Parent != nil && !fileExists(initializer) {
+		p.inflections = p.Parent.Inflections()
+		return p.inflections
+	}
+	p.inflections, _ = inflect.LoadInitializer(initializer)
+	return p.inflections
+}
+
+// IsModel checks if the file is a Rails model, excluding concerns
+func (s *SourceFile) IsModel() bool {
+	return strings.HasPrefix(s.Filename, "app/models/") && strings.HasSuffix(s.Filename, ".rb") &&
+		!strings.HasPrefix(s.Filename, "app/models/concerns/")
+}
+
+// IsFactory checks if the file is a FactoryBot factory file in
+// spec/factories or test/factories
+func (s *SourceFile) IsFactory() bool {
+	return s.factoryDir() != ""
+}
+
+// factoryDir returns the factories directory containing the file, or ""
+func (s *SourceFile) factoryDir() string {
+	if !strings.HasSuffix(s.Filename, ".rb") {
+		return ""
+	}
+	for _, project := range s.Project.frameworkViews() {
+		dir := path.Join(project.TestAnchor(), "factories")
+		if strings.HasPrefix(s.Filename, dir+"/") {
+			return dir
+		}
+	}
+	return ""
+}
+
+// FactoryCandidates returns the factory files tried for a model, its
+// tests or its factory, relative to the project root and in the order
+// they are tried. Factory files are named after the pluralized model,
+// nested like the model or flattened: app/models/billing/invoice.rb has
+// its factories in spec/factories/billing/invoices.rb or
+// spec/factories/billing_invoices.rb.
+func (s *SourceFile) FactoryCandidates() []string {
+	if s.IsFactory() {
+		return []string{s.Filename}
+	}
+	model := s.source()
+	if !model.IsModel() {
+		return nil
+	}
+
+	name := strings.TrimSuffix(strings.TrimPrefix(model.Filename, "app/models/"), ".rb")
+	dir, base := path.Split(name)
+	flat := strings.ReplaceAll(name, "/", "_")
+	inflections := s.Project.Inflections()
+
+	var candidates []string
+	for _, project := range s.Project.frameworkViews() {
+		factories := path.Join(project.TestAnchor(), "factories")
+		candidates = append(candidates,
+			path.Join(factories, dir, inflections.Pluralize(base)+".rb"),
+			path.Join(factories, inflections.Pluralize(flat)+".rb"),
+			path.Join(factories, name+".rb"))
+	}
+	return uniqueCandidates(candidates)
+}
+
+// FactoryFile returns the absolute path of the first existing factory
+// file for the model, or ""
+func (s *SourceFile) FactoryFile() string {
+	return s.firstExisting(s.FactoryCandidates())
+}
+
+// modelCandidates lists the models a factory file defines factories for.
+// A flattened name such as billing_invoices.rb also maps to the models of
+// the existing namespaces it starts with, e.g. app/models/billing/invoice.rb.
+func (s *SourceFile) modelCandidates() []string {
+	dir := s.factoryDir()
+	if dir == "" {
+		return nil
+	}
+	name := strings.TrimSuffix(strings.TrimPrefix(s.Filename, dir+"/"), ".rb")
+	nested, base := path.Split(name)
+	inflections := s.Project.Inflections()
+
+	candidates := []string{
+		path.Join("app", "models", nested, inflections.Singularize(base)+".rb"),
+		path.Join("app", "models", name+".rb"),
+	}
+	candidates = append(candidates, s.namespacedModels(path.Join("app", "models", nested), strings.Split(base, "_"))...)
+	return uniqueCandidates(candidates)
+}
+
+// namespacedModels lists the models for the words of a flattened factory
+// name within dir, taking leading words that name an existing namespace
+// directory as the namespace, most nested first
+func (s *SourceFile) namespacedModels(dir string, words []string) []string {
+	var candidates []string
+	for i := 1; i < len(words); i++ {
+		namespace := path.Join(dir, strings.Join(words[:i], "_"))
+		if !fileExists(filepath.Join(s.Project.Root, filepath.FromSlash(namespace))) {
+			continue
+		}
+		rest := words[i:]
+		candidates = append(candidates, s.namespacedModels(namespace, rest)...)
+		candidates = append(candidates, path.Join(namespace, s.Project.Inflections().Singularize(strings.Join(rest, "_"))+".rb"))
+	}
+	return candidates
+}
+
+// source returns the source file for a test file, resolved through its
+// alternate, or the file itself
+func (s *SourceFile) source() *SourceFile {
+	if !s.IsTestFile() {
+		return s
+	}
+	alternate := s.AlternateFile()
+	if alternate == "" {
+		return s
+	}
+	rel, err := filepath.Rel(s.Project.Root, alternate)
+	if err != nil {
+		return s
+	}
+	return NewSourceFile(filepath.ToSlash(rel), s.Project)
+}
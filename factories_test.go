@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSourceFile_FactoryCandidates(t *testing.T) {
+	tests := []struct {
+		name     string
+		helper   string
+		file     string
+		expected []string
+	}{
+		{
+			name:     "rspec model",
+			helper:   ".rspec",
+			file:     "app/models/person.rb",
+			expected: []string{"spec/factories/people.rb", "spec/factories/person.rb"},
+		},
+		{
+			name:   "namespaced model",
+			helper: ".rspec",
+			file:   "app/models/billing/invoice.rb",
+			expected: []string{
+				"spec/factories/billing/invoices.rb",
+				"spec/factories/billing_invoices.rb",
+				"spec/factories/billing/invoice.rb",
+			},
+		},
+		{
+			name:     "minitest model",
+			helper:   "test/test_helper.rb",
+			file:     "app/models/category.rb",
+			expected: []string{"test/factories/categories.rb", "test/factories/category.rb"},
+		},
+		{
+			name:     "concern",
+			helper:   ".rspec",
+			file:     "app/models/concerns/archivable.rb",
+			expected: nil,
+		},
+		{
+			name:     "not a model",
+			helper:   ".rspec",
+			file:     "app/services/user_service.rb",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, filepath.Join(dir, tt.helper), "")
+			got := NewSourceFile(tt.file, NewProject(dir)).FactoryCandidates()
+			if !equalStrings(got, tt.expected) {
+				t.Errorf("FactoryCandidates() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// setupFactoryProject creates a model with a spec and a factory file
+func setupFactoryProject(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".rspec"), "")
+	writeFile(t, filepath.Join(dir, "app", "models", "line_item.rb"), "")
+	writeFile(t, filepath.Join(dir, "spec", "models", "line_item_spec.rb"), "")
+	writeFile(t, filepath.Join(dir, "spec", "factories", "line_items.rb"), "")
+	return dir
+}
+
+func TestSourceFile_FactoryFile(t *testing.T) {
+	dir := setupFactoryProject(t)
+	project := NewProject(dir)
+	factory := filepath.Join(dir, "spec", "factories", "line_items.rb")
+
+	for _, file := range []string{"app/models/line_item.rb", "spec/models/line_item_spec.rb"} {
+		if got := NewSourceFile(file, project).FactoryFile(); got != factory {
+			t.Errorf("FactoryFile(%q) = %q, want %q", file, got, factory)
+		}
+	}
+
+	// The factory file toggles back to its model
+	sourceFile := NewSourceFile("spec/factories/line_items.rb", project)
+	if !sourceFile.IsFactory() || sourceFile.IsTestFile() {
+		t.Errorf("IsFactory() = %v, IsTestFile() = %v", sourceFile.IsFactory(), sourceFile.IsTestFile())
+	}
+	if got, want := sourceFile.AlternateFile(), filepath.Join(dir, "app", "models", "line_item.rb"); got != want {
+		t.Errorf("AlternateFile() = %q, want %q", got, want)
+	}
+
+	// The model's alternate is still its spec
+	if got, want := NewSourceFile("app/models/line_item.rb", project).AlternateFile(), filepath.Join(dir, "spec", "models", "line_item_spec.rb"); got != want {
+		t.Errorf("AlternateFile() = %q, want %q", got, want)
+	}
+}
+
+func TestSourceFile_RelatedFactory(t *testing.T) {
+	dir := setupFactoryProject(t)
+	got := NewSourceFile("spec/models/line_item_spec.rb", NewProject(dir)).RelatedFiles()
+	expected := []string{"app/models/line_item.rb", "spec/models/line_item_spec.rb", "spec/factories/line_items.rb"}
+	if !equalStrings(got, expected) {
+		t.Errorf("RelatedFiles() = %v, want %v", got, expected)
+	}
+}
+
+func TestCLI_RunFactory(t *testing.T) {
+	dir := setupFactoryProject(t)
+
+	tests := map[string]string{
+		"app/models/line_item.rb":      filepath.Join(dir, "spec", "factories", "line_items.rb"),
+		"spec/factories/line_items.rb": filepath.Join(dir, "app", "models", "line_item.rb"),
+	}
+	for path, expected := range tests {
+		var stdout bytes.Buffer
+		cli := &CLI{Command: "factory", Root: dir, Path: path, Print: true, Stdout: &stdout}
+		if err := cli.Run(); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		if got := strings.TrimSpace(stdout.String()); got != expected {
+			t.Errorf("factory -p %s printed %q, want %q", path, got, expected)
+		}
+	}
+}
+
+func TestSourceFile_FlattenedFactoryRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".rspec"), "")
+	writeFile(t, filepath.Join(dir, "app", "models", "billing", "invoice.rb"), "")
+	writeFile(t, filepath.Join(dir, "app", "models", "billing", "line_items", "tax_rate.rb"), "")
+	writeFile(t, filepath.Join(dir, "spec", "factories", "billing_invoices.rb"), "")
+	writeFile(t, filepath.Join(dir, "spec", "factories", "billing_line_items_tax_rates.rb"), "")
+	project := NewProject(dir)
+
+	tests := map[string]string{
+		"app/models/billing/invoice.rb":             "spec/factories/billing_invoices.rb",
+		"app/models/billing/line_items/tax_rate.rb": "spec/factories/billing_line_items_tax_rates.rb",
+	}
+	for model, factory := range tests {
+		if got, want := NewSourceFile(model, project).FactoryFile(), filepath.Join(dir, factory); got != want {
+			t.Errorf("FactoryFile(%q) = %q, want %q", model, got, want)
+		}
+		if got, want := NewSourceFile(factory, project).AlternateFile(), filepath.Join(dir, model); got != want {
+			t.Errorf("AlternateFile(%q) = %q, want %q", factory, got, want)
+		}
+
+		var stdout bytes.Buffer
+		cli := &CLI{Command: "factory", Root: dir, Path: factory, Print: true, Stdout: &stdout}
+		if err := cli.Run(); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		if got, want := strings.TrimSpace(stdout.String()), filepath.Join(dir, model); got != want {
+			t.Errorf("factory -p %s printed %q, want %q", factory, got, want)
+		}
+	}
+}
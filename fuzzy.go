@@ -0,0 +1,162 @@
+package main
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// DefaultFuzzyThreshold is the score a fuzzy match needs to be opened
+// directly, see Config.FuzzyThreshold. Only a file in the same directories
+// scores 1: a file of the same name elsewhere, e.g. the spec of User for
+// Admin::User, is listed rather than opened.
+const DefaultFuzzyThreshold = 1.0
+
+// minFuzzyThreshold is the lowest score of a fuzzy match, that of a file
+// whose name matches in unrelated directories
+const minFuzzyThreshold = 0.5
+
+// FuzzyMatch is a file found by the fuzzy fallback search
+type FuzzyMatch struct {
+	// Path is relative to the project root
+	Path string `json:"path"`
+	// Score ranges from 0.5 for a file named like the alternate to 1
+	// when its directories also match
+	Score float64 `json:"score"`
+}
+
+// FuzzyThreshold returns the score a fuzzy match needs to be opened
+// directly. Configured thresholds below the lowest score act as it.
+func (p *Project) FuzzyThreshold() float64 {
+	if threshold := p.config().FuzzyThreshold; threshold != nil {
+		return max(*threshold, minFuzzyThreshold)
+	}
+	return DefaultFuzzyThreshold
+}
+
+// FuzzyMatches searches the project for the alternate when none of the
+// candidates exists. A source file matches the test files in the test
+// paths that test a file of the same name, e.g. lib/user.rb matches
+// spec/services/legacy/user_spec.rb; a test file matches the source files
+// of the same name in the source paths. Files in a package are searched
+// within it. Matches are ranked by how alike the directories below the
+// source and test paths are, best first.
+func (s *SourceFile) FuzzyMatches() []FuzzyMatch {
+	if scoped, dir := s.scoped(); dir != "" {
+		matches := scoped.FuzzyMatches()
+		for i := range matches {
+			matches[i].Path = path.Join(dir, matches[i].Path)
+		}
+		return matches
+	}
+
+	isTest := s.IsTestFile()
+	name, dirs := s.Project.fuzzyKey(s.Filename, isTest)
+	if name == "" {
+		return nil
+	}
+
+	// Only the paths the alternate may be in are searched
+	searched := s.Project.allTestPaths()
+	if isTest {
+		searched = s.Project.SrcPaths()
+	}
+	files, err := s.Project.rubyFilesBelow(searched)
+	if err != nil {
+		return nil
+	}
+
+	var matches []FuzzyMatch
+	for _, file := range files {
+		if file == s.Filename || s.Project.isTestName(file) == isTest {
+			continue
+		}
+		fileName, fileDirs := s.Project.fuzzyKey(file, !isTest)
+		if fileName != name {
+			continue
+		}
+		matches = append(matches, FuzzyMatch{Path: file, Score: 0.5 + 0.5*similarity(dirs, fileDirs)})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	return matches
+}
+
+// ConfidentMatch returns the best fuzzy match when it reaches the
+// threshold and ranks strictly above the next one, or ""
+func (p *Project) ConfidentMatch(matches []FuzzyMatch) string {
+	if len(matches) == 0 || matches[0].Score < p.FuzzyThreshold() {
+		return ""
+	}
+	if len(matches) > 1 && matches[1].Score == matches[0].Score {
+		return ""
+	}
+	return matches[0].Path
+}
+
+// fuzzyKey returns the name a file is matched by, the source name without
+// .rb, and its directories below the source or test path holding it. The
+// name is "" for a test file that isn't in the test paths.
+func (p *Project) fuzzyKey(file string, isTest bool) (string, []string) {
+	roots := p.SrcPaths()
+	if isTest {
+		roots = nil
+		source := ""
+		for _, project := range p.frameworkViews() {
+			if untestified, ok := project.Untestify(file); ok && source == "" {
+				source = untestified
+				roots = project.TestPaths()
+			}
+		}
+		if source == "" {
+			return "", nil
+		}
+		file = source
+	}
+
+	// Strip the longest root holding the file
+	dir := path.Dir(file)
+	longest := -1
+	for _, root := range roots {
+		if root == "" || dir == root || strings.HasPrefix(dir, root+"/") {
+			if len(root) > longest {
+				longest = len(root)
+			}
+		}
+	}
+	if longest < 0 {
+		if isTest {
+			return "", nil
+		}
+		longest = 0
+	}
+	dir = strings.TrimPrefix(dir[longest:], "/")
+
+	var dirs []string
+	if dir != "" && dir != "." {
+		dirs = strings.Split(dir, "/")
+	}
+	return strings.TrimSuffix(path.Base(file), ".rb"), dirs
+}
+
+// similarity scores how many directory names two paths share, from 0 for
+// none to 1 for the same directories in any order
+func similarity(a, b []string) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	counts := map[string]int{}
+	for _, dir := range a {
+		counts[dir]++
+	}
+	shared := 0
+	for _, dir := range b {
+		if counts[dir] > 0 {
+			counts[dir]--
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(a)+len(b))
+}
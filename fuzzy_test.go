@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+// setupFuzzyProject creates an RSpec project whose specs don't mirror
+// the source layout
+func setupFuzzyProject(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".rspec"), "")
+	for _, file := range []string{
+		"lib/user.rb",
+		"lib/billing/invoice.rb",
+		"lib/report.rb",
+		"lib/legacy/audit/log.rb",
+		"spec/services/legacy/user_spec.rb",
+		"spec/legacy/billing/invoice_spec.rb",
+		"spec/legacy/other/invoice_spec.rb",
+		"spec/a/report_spec.rb",
+		"spec/b/report_spec.rb",
+		"spec/audit/legacy/log_spec.rb",
+		"spec/support/user.rb",
+	} {
+		writeFile(t, filepath.Join(dir, file), "")
+	}
+	return dir
+}
+
+func TestSourceFile_FuzzyMatches(t *testing.T) {
+	dir := setupFuzzyProject(t)
+	writeFile(t, filepath.Join(dir, ConfigFileName), `{"fuzzy_threshold": 0.5}`)
+	project := NewProject(dir)
+
+	tests := []struct {
+		file      string
+		expected  []FuzzyMatch
+		confident string
+	}{
+		{
+			file:      "lib/user.rb",
+			expected:  []FuzzyMatch{{"spec/services/legacy/user_spec.rb", 0.5}},
+			confident: "spec/services/legacy/user_spec.rb",
+		},
+		{
+			file: "lib/billing/invoice.rb",
+			expected: []FuzzyMatch{
+				{"spec/legacy/billing/invoice_spec.rb", 0.5 + 0.5*2.0/3},
+				{"spec/legacy/other/invoice_spec.rb", 0.5},
+			},
+			confident: "spec/legacy/billing/invoice_spec.rb",
+		},
+		{
+			file: "lib/report.rb",
+			expected: []FuzzyMatch{
+				{"spec/a/report_spec.rb", 0.5},
+				{"spec/b/report_spec.rb", 0.5},
+			},
+		},
+		{
+			file:      "lib/legacy/audit/log.rb",
+			expected:  []FuzzyMatch{{"spec/audit/legacy/log_spec.rb", 1}},
+			confident: "spec/audit/legacy/log_spec.rb",
+		},
+		{
+			file:      "spec/services/legacy/user_spec.rb",
+			expected:  []FuzzyMatch{{"lib/user.rb", 0.5}},
+			confident: "lib/user.rb",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			matches := NewSourceFile(tt.file, project).FuzzyMatches()
+			if len(matches) != len(tt.expected) {
+				t.Fatalf("FuzzyMatches() = %v, want %v", matches, tt.expected)
+			}
+			for i, match := range matches {
+				if match.Path != tt.expected[i].Path || math.Abs(match.Score-tt.expected[i].Score) > 1e-9 {
+					t.Errorf("FuzzyMatches()[%d] = %v, want %v", i, match, tt.expected[i])
+				}
+			}
+			if got := project.ConfidentMatch(matches); got != tt.confident {
+				t.Errorf("ConfidentMatch() = %q, want %q", got, tt.confident)
+			}
+		})
+	}
+}
+
+func TestSourceFile_FuzzyMatchesInPackage(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".rspec"), "")
+	writeFile(t, filepath.Join(dir, "spec", "legacy", "invoice_spec.rb"), "")
+	writeFile(t, filepath.Join(dir, "packs", "billing", "package.yml"), "")
+	writeFile(t, filepath.Join(dir, "packs", "billing", "app", "models", "invoice.rb"), "")
+	writeFile(t, filepath.Join(dir, "packs", "billing", "spec", "legacy", "invoice_spec.rb"), "")
+
+	matches := NewSourceFile("packs/billing/app/models/invoice.rb", NewProject(dir)).FuzzyMatches()
+	if len(matches) != 1 || matches[0].Path != "packs/billing/spec/legacy/invoice_spec.rb" {
+		t.Errorf("FuzzyMatches() = %v, want the spec in the package only", matches)
+	}
+}
+
+func TestProject_FuzzyThreshold(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    string
+		file      string
+		confident string
+	}{
+		{name: "default ignores a match in other directories", file: "lib/user.rb"},
+		{name: "default ignores a partial directory match", file: "lib/billing/invoice.rb"},
+		{name: "default opens a match in the same directories", file: "lib/legacy/audit/log.rb", confident: "spec/audit/legacy/log_spec.rb"},
+		{name: "above the best score", config: `{"fuzzy_threshold": 0.9}`, file: "lib/billing/invoice.rb"},
+		{name: "below the best score", config: `{"fuzzy_threshold": 0.8}`, file: "lib/billing/invoice.rb", confident: "spec/legacy/billing/invoice_spec.rb"},
+		{name: "below the lowest score", config: `{"fuzzy_threshold": 0.3}`, file: "lib/user.rb", confident: "spec/services/legacy/user_spec.rb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := setupFuzzyProject(t)
+			if tt.config != "" {
+				writeFile(t, filepath.Join(dir, ConfigFileName), tt.config)
+			}
+			project := NewProject(dir)
+			if project.ConfigErr != nil {
+				t.Fatalf("unexpected config error: %v", project.ConfigErr)
+			}
+
+			matches := NewSourceFile(tt.file, project).FuzzyMatches()
+			if got := project.ConfidentMatch(matches); got != tt.confident {
+				t.Errorf("ConfidentMatch() = %q, want %q", got, tt.confident)
+			}
+		})
+	}
+
+	dir := setupFuzzyProject(t)
+	writeFile(t, filepath.Join(dir, ConfigFileName), `{"fuzzy_threshold": 1.5}`)
+	if project := NewProject(dir); project.ConfigErr == nil {
+		t.Error("expected an error for a threshold above 1")
+	}
+}
+
+func TestCLI_RunLookupFuzzy(t *testing.T) {
+	dir := setupFuzzyProject(t)
+
+	t.Run("confident", func(t *testing.T) {
+		var out bytes.Buffer
+		cli := &CLI{Command: "lookup", Root: dir, Path: "lib/legacy/audit/log.rb", Print: true, Stdout: &out}
+		if err := cli.Run(); err != nil {
+			t.Fatalf("Run() unexpected error: %v", err)
+		}
+		if got, want := out.String(), filepath.Join(dir, "spec/audit/legacy/log_spec.rb")+"\n"; got != want {
+			t.Errorf("output = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("nothing printed without a confident match", func(t *testing.T) {
+		var out bytes.Buffer
+		cli := &CLI{Command: "lookup", Root: dir, Path: "lib/report.rb", Print: true, Stdout: &out}
+		if err := cli.Run(); err != nil {
+			t.Fatalf("Run() unexpected error: %v", err)
+		}
+		if got := out.String(); got != "" {
+			t.Errorf("output = %q, want none", got)
+		}
+	})
+
+	t.Run("ranked list", func(t *testing.T) {
+		var out bytes.Buffer
+		cli := &CLI{Command: "lookup", Root: dir, Path: "lib/report.rb", Stdout: &out}
+		if err := cli.Run(); err != nil {
+			t.Fatalf("Run() unexpected error: %v", err)
+		}
+		want := "0.50  " + filepath.Join(dir, "spec/a/report_spec.rb") + "\n" +
+			"0.50  " + filepath.Join(dir, "spec/b/report_spec.rb") + "\n"
+		if got := out.String(); got != want {
+			t.Errorf("output = %q, want %q", got, want)
+		}
+	})
+}
+
+func TestCLI_RunLookupCreateIgnoresFuzzyMatches(t *testing.T) {
+	for _, config := range []string{"", `{"fuzzy_threshold": 0.5}`} {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, ".rspec"), "")
+		writeFile(t, filepath.Join(dir, "app", "models", "admin", "user.rb"), "")
+		writeFile(t, filepath.Join(dir, "spec", "models", "user_spec.rb"), "")
+		if config != "" {
+			writeFile(t, filepath.Join(dir, ConfigFileName), config)
+		}
+
+		var out bytes.Buffer
+		cli := &CLI{Command: "lookup", Root: dir, Path: "app/models/admin/user.rb", Create: true, Print: true, Stdout: &out}
+		if err := cli.Run(); err != nil {
+			t.Fatalf("Run() unexpected error: %v", err)
+		}
+		want := filepath.Join(dir, "spec", "models", "admin", "user_spec.rb")
+		if got := out.String(); got != want+"\n" {
+			t.Errorf("config %q: output = %q, want %q", config, got, want+"\n")
+		}
+		if !fileExists(want) {
+			t.Errorf("config %q: %s not created", config, want)
+		}
+	}
+}
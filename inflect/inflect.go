@@ -0,0 +1,352 @@
+// Package inflect transforms English words the way Rails'
+// ActiveSupport::Inflector does: pluralize, singularize, camelize and
+// underscore, with irregular words, uncountable words and acronyms.
+package inflect
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Inflections holds the rules used to transform words. Rules added later
+// take precedence, as in Rails.
+type Inflections struct {
+	plurals      []rule
+	singulars    []rule
+	uncountables []string
+	// acronyms maps the lowercase form of each acronym to its spelling,
+	// and acronymOrder keeps the order they were declared in
+	acronyms     map[string]string
+	acronymOrder []string
+}
+
+var (
+	// camelizeFirstRegex matches the lowercase start of the first word
+	camelizeFirstRegex = regexp.MustCompile(`^[a-z\d]*`)
+	// camelizeRestRegex matches the following words and path segments
+	camelizeRestRegex = regexp.MustCompile(`(?i)(?:_|(/))([a-z\d]*)`)
+)
+
+// rule replaces the match of a pattern; the replacement may refer to
+// groups as ${1}
+type rule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// New returns the Rails default inflections
+func New() *Inflections {
+	i := &Inflections{acronyms: map[string]string{}}
+
+	i.mustPlural(`$`, "s")
+	i.mustPlural(`s$`, "s")
+	i.mustPlural(`^(ax|test)is$`, "${1}es")
+	i.mustPlural(`(octop|vir)us$`, "${1}i")
+	i.mustPlural(`(octop|vir)i$`, "${1}i")
+	i.mustPlural(`(alias|status)$`, "${1}es")
+	i.mustPlural(`(bu)s$`, "${1}ses")
+	i.mustPlural(`(buffal|tomat)o$`, "${1}oes")
+	i.mustPlural(`([ti])um$`, "${1}a")
+	i.mustPlural(`([ti])a$`, "${1}a")
+	i.mustPlural(`sis$`, "ses")
+	i.mustPlural(`(?:([^f])fe|([lr])f)$`, "${1}${2}ves")
+	i.mustPlural(`(hive)$`, "${1}s")
+	i.mustPlural(`([^aeiouy]|qu)y$`, "${1}ies")
+	i.mustPlural(`(x|ch|ss|sh)$`, "${1}es")
+	i.mustPlural(`(matr|vert|ind)(?:ix|ex)$`, "${1}ices")
+	i.mustPlural(`^(m|l)ouse$`, "${1}ice")
+	i.mustPlural(`^(m|l)ice$`, "${1}ice")
+	i.mustPlural(`^(ox)$`, "${1}en")
+	i.mustPlural(`^(oxen)$`, "${1}")
+	i.mustPlural(`(quiz)$`, "${1}zes")
+
+	i.mustSingular(`s$`, "")
+	i.mustSingular(`(ss)$`, "${1}")
+	i.mustSingular(`(n)ews$`, "${1}ews")
+	i.mustSingular(`([ti])a$`, "${1}um")
+	i.mustSingular(`((a)naly|(b)a|(d)iagno|(p)arenthe|(p)rogno|(s)ynop|(t)he)(sis|ses)$`, "${1}sis")
+	i.mustSingular(`(^analy)(sis|ses)$`, "${1}sis")
+	i.mustSingular(`([^f])ves$`, "${1}fe")
+	i.mustSingular(`(hive)s$`, "${1}")
+	i.mustSingular(`(tive)s$`, "${1}")
+	i.mustSingular(`([lr])ves$`, "${1}f")
+	i.mustSingular(`([^aeiouy]|qu)ies$`, "${1}y")
+	i.mustSingular(`(s)eries$`, "${1}eries")
+	i.mustSingular(`(m)ovies$`, "${1}ovie")
+	i.mustSingular(`(x|ch|ss|sh)es$`, "${1}")
+	i.mustSingular(`^(m|l)ice$`, "${1}ouse")
+	i.mustSingular(`(bus)(es)?$`, "${1}")
+	i.mustSingular(`(o)es$`, "${1}")
+	i.mustSingular(`(shoe)s$`, "${1}")
+	i.mustSingular(`(cris|test)(is|es)$`, "${1}is")
+	i.mustSingular(`^(a)x[ie]s$`, "${1}xis")
+	i.mustSingular(`(octop|vir)(us|i)$`, "${1}us")
+	i.mustSingular(`(alias|status)(es)?$`, "${1}")
+	i.mustSingular(`^(ox)en`, "${1}")
+	i.mustSingular(`(vert|ind)ices$`, "${1}ex")
+	i.mustSingular(`(matr)ices$`, "${1}ix")
+	i.mustSingular(`(quiz)zes$`, "${1}")
+	i.mustSingular(`(database)s$`, "${1}")
+
+	i.Irregular("person", "people")
+	i.Irregular("man", "men")
+	i.Irregular("child", "children")
+	i.Irregular("sex", "sexes")
+	i.Irregular("move", "moves")
+	i.Irregular("zombie", "zombies")
+
+	i.Uncountable("equipment", "information", "rice", "money", "species", "series", "fish", "sheep", "jeans", "police")
+	return i
+}
+
+// Plural adds a pluralization rule. The pattern is a Go regular
+// expression, case sensitive unless it starts with (?i); the replacement
+// refers to groups as ${1}.
+func (i *Inflections) Plural(pattern, replacement string) error {
+	r, err := newRule(pattern, replacement)
+	if err != nil {
+		return err
+	}
+	i.uncountables = removeString(i.uncountables, strings.ToLower(replacement))
+	i.plurals = append([]rule{r}, i.plurals...)
+	return nil
+}
+
+// Singular adds a singularization rule, see Plural
+func (i *Inflections) Singular(pattern, replacement string) error {
+	r, err := newRule(pattern, replacement)
+	if err != nil {
+		return err
+	}
+	i.uncountables = removeString(i.uncountables, strings.ToLower(replacement))
+	i.singulars = append([]rule{r}, i.singulars...)
+	return nil
+}
+
+// mustPlural adds a case insensitive built-in pluralization rule
+func (i *Inflections) mustPlural(pattern, replacement string) {
+	i.plurals = append([]rule{{regexp.MustCompile(`(?i)` + pattern), replacement}}, i.plurals...)
+}
+
+// mustSingular adds a case insensitive built-in singularization rule
+func (i *Inflections) mustSingular(pattern, replacement string) {
+	i.singulars = append([]rule{{regexp.MustCompile(`(?i)` + pattern), replacement}}, i.singulars...)
+}
+
+// Irregular adds a word whose plural doesn't follow the rules. It also
+// applies to compound words ending with it, e.g. salesperson.
+func (i *Inflections) Irregular(singular, plural string) {
+	if singular == "" || plural == "" {
+		return
+	}
+	i.uncountables = removeString(i.uncountables, strings.ToLower(singular))
+	i.uncountables = removeString(i.uncountables, strings.ToLower(plural))
+
+	s0, sRest := singular[:1], regexp.QuoteMeta(singular[1:])
+	p0, pRest := plural[:1], regexp.QuoteMeta(plural[1:])
+	if strings.EqualFold(s0, p0) {
+		i.mustPlural(`(`+regexp.QuoteMeta(s0)+`)`+sRest+`$`, "${1}"+plural[1:])
+		i.mustPlural(`(`+regexp.QuoteMeta(p0)+`)`+pRest+`$`, "${1}"+plural[1:])
+		i.mustSingular(`(`+regexp.QuoteMeta(s0)+`)`+sRest+`$`, "${1}"+singular[1:])
+		i.mustSingular(`(`+regexp.QuoteMeta(p0)+`)`+pRest+`$`, "${1}"+singular[1:])
+		return
+	}
+	for _, c := range []func(string) string{strings.ToUpper, strings.ToLower} {
+		i.plurals = append([]rule{
+			{regexp.MustCompile(regexp.QuoteMeta(c(s0)) + `(?i:` + sRest + `)$`), c(p0) + plural[1:]},
+			{regexp.MustCompile(regexp.QuoteMeta(c(p0)) + `(?i:` + pRest + `)$`), c(p0) + plural[1:]},
+		}, i.plurals...)
+		i.singulars = append([]rule{
+			{regexp.MustCompile(regexp.QuoteMeta(c(s0)) + `(?i:` + sRest + `)$`), c(s0) + singular[1:]},
+			{regexp.MustCompile(regexp.QuoteMeta(c(p0)) + `(?i:` + pRest + `)$`), c(s0) + singular[1:]},
+		}, i.singulars...)
+	}
+}
+
+// Uncountable adds words that are the same in singular and plural
+func (i *Inflections) Uncountable(words ...string) {
+	for _, word := range words {
+		i.uncountables = append(i.uncountables, strings.ToLower(word))
+	}
+}
+
+// Acronym adds a word that keeps its spelling when camelized, e.g. "API"
+// or "RESTful", and is underscored as a single word
+func (i *Inflections) Acronym(word string) {
+	if word == "" {
+		return
+	}
+	lower := strings.ToLower(word)
+	if _, ok := i.acronyms[lower]; !ok {
+		i.acronymOrder = append(i.acronymOrder, lower)
+	}
+	i.acronyms[lower] = word
+}
+
+// Clear removes every rule, irregular, uncountable and acronym
+func (i *Inflections) Clear() {
+	*i = Inflections{acronyms: map[string]string{}}
+}
+
+// Pluralize returns the plural form of a word, e.g. "person" → "people"
+func (i *Inflections) Pluralize(word string) string {
+	return i.apply(word, i.plurals)
+}
+
+// Singularize returns the singular form of a word, e.g. "people" → "person"
+func (i *Inflections) Singularize(word string) string {
+	return i.apply(word, i.singulars)
+}
+
+// Camelize converts an underscored path to a constant name, e.g.
+// "api/v1/users_controller" → "Api::V1::UsersController", or
+// "API::V1::UsersController" when API is an acronym
+func (i *Inflections) Camelize(term string) string {
+	term = camelizeFirstRegex.ReplaceAllStringFunc(term, i.capitalize)
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range camelizeRestRegex.FindAllStringSubmatchIndex(term, -1) {
+		b.WriteString(term[last:loc[0]])
+		if loc[2] >= 0 {
+			b.WriteString("::")
+		}
+		b.WriteString(i.capitalize(term[loc[4]:loc[5]]))
+		last = loc[1]
+	}
+	b.WriteString(term[last:])
+	return strings.ReplaceAll(b.String(), "/", "::")
+}
+
+// Underscore converts a constant name to an underscored path, e.g.
+// "API::V1::UsersController" → "api/v1/users_controller" when API is an
+// acronym
+func (i *Inflections) Underscore(word string) string {
+	if !strings.ContainsAny(word, "ABCDEFGHIJKLMNOPQRSTUVWXYZ-") && !strings.Contains(word, "::") {
+		return word
+	}
+	word = strings.ReplaceAll(word, "::", "/")
+	word = i.underscoreAcronyms(word)
+
+	var b strings.Builder
+	for n := 0; n < len(word); n++ {
+		if n > 0 && isUpper(word[n]) {
+			prev := word[n-1]
+			// Split "HTMLParser" before "Parser" and "userName" before "Name"
+			if (isUpper(prev) && n+1 < len(word) && isLower(word[n+1])) || isLower(prev) || isDigit(prev) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteByte(word[n])
+	}
+	return strings.ToLower(strings.ReplaceAll(b.String(), "-", "_"))
+}
+
+// capitalize returns the acronym spelling of a word, or the word with an
+// uppercase first letter and the rest lowercase
+func (i *Inflections) capitalize(word string) string {
+	if acronym, ok := i.acronyms[word]; ok {
+		return acronym
+	}
+	if word == "" {
+		return word
+	}
+	return strings.ToUpper(word[:1]) + strings.ToLower(word[1:])
+}
+
+// underscoreAcronyms lowercases the acronyms in a word, separating them
+// from a preceding letter or digit with an underscore. An acronym is only
+// recognized when not followed by a lowercase letter.
+func (i *Inflections) underscoreAcronyms(word string) string {
+	if len(i.acronymOrder) == 0 {
+		return word
+	}
+
+	var b strings.Builder
+	for n := 0; n < len(word); {
+		matched := ""
+		prevAlnum := n > 0 && isAlnum(word[n-1])
+		atBoundary := n == 0 || !isWordChar(word[n-1])
+		if prevAlnum || atBoundary {
+			for _, lower := range i.acronymOrder {
+				acronym := i.acronyms[lower]
+				end := n + len(acronym)
+				if strings.HasPrefix(word[n:], acronym) && (end == len(word) || !isLower(word[end])) {
+					matched = acronym
+					break
+				}
+			}
+		}
+		if matched == "" {
+			b.WriteByte(word[n])
+			n++
+			continue
+		}
+		if prevAlnum {
+			b.WriteByte('_')
+		}
+		b.WriteString(strings.ToLower(matched))
+		n += len(matched)
+	}
+	return b.String()
+}
+
+// apply replaces the word with the first matching rule, leaving empty and
+// uncountable words unchanged
+func (i *Inflections) apply(word string, rules []rule) string {
+	if word == "" || i.isUncountable(word) {
+		return word
+	}
+	for _, r := range rules {
+		if loc := r.pattern.FindStringSubmatchIndex(word); loc != nil {
+			replaced := r.pattern.ExpandString(nil, r.replacement, word, loc)
+			return word[:loc[0]] + string(replaced) + word[loc[1]:]
+		}
+	}
+	return word
+}
+
+// isUncountable reports whether the last word of the string, as matched
+// by \b\w+$, is uncountable
+func (i *Inflections) isUncountable(word string) bool {
+	lower := strings.ToLower(word)
+	for _, uncountable := range i.uncountables {
+		if !strings.HasSuffix(lower, uncountable) {
+			continue
+		}
+		rest := lower[:len(lower)-len(uncountable)]
+		if rest == "" || !isWordChar(rest[len(rest)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// newRule compiles a rule
+func newRule(pattern, replacement string) (rule, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return rule{}, fmt.Errorf("invalid inflection rule %q: %w", pattern, err)
+	}
+	return rule{re, replacement}, nil
+}
+
+func isUpper(c byte) bool { return 'A' <= c && c <= 'Z' }
+func isLower(c byte) bool { return 'a' <= c && c <= 'z' }
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+func isAlnum(c byte) bool { return isUpper(c) || isLower(c) || isDigit(c) }
+
+// isWordChar reports whether the byte matches \w
+func isWordChar(c byte) bool { return isAlnum(c) || c == '_' }
+
+// removeString returns the list without the value
+func removeString(list []string, value string) []string {
+	var kept []string
+	for _, item := range list {
+		if item != value {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
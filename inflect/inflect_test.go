@@ -0,0 +1,133 @@
+package inflect
+
+import "testing"
+
+func TestInflections_PluralizeSingularize(t *testing.T) {
+	tests := []struct {
+		singular string
+		plural   string
+	}{
+		{"user", "users"},
+		{"invoice", "invoices"},
+		{"category", "categories"},
+		{"query", "queries"},
+		{"day", "days"},
+		{"address", "addresses"},
+		{"box", "boxes"},
+		{"match", "matches"},
+		{"wish", "wishes"},
+		{"status", "statuses"},
+		{"alias", "aliases"},
+		{"bus", "buses"},
+		{"tomato", "tomatoes"},
+		{"medium", "media"},
+		{"analysis", "analyses"},
+		{"knife", "knives"},
+		{"wolf", "wolves"},
+		{"hive", "hives"},
+		{"matrix", "matrices"},
+		{"vertex", "vertices"},
+		{"mouse", "mice"},
+		{"ox", "oxen"},
+		{"quiz", "quizzes"},
+		{"octopus", "octopi"},
+		{"axis", "axes"},
+		{"person", "people"},
+		{"salesperson", "salespeople"},
+		{"man", "men"},
+		{"child", "children"},
+		{"move", "moves"},
+		{"equipment", "equipment"},
+		{"sheep", "sheep"},
+		{"series", "series"},
+		{"line_item", "line_items"},
+		{"admin_user", "admin_users"},
+		{"Person", "People"},
+	}
+
+	inflections := New()
+	for _, tt := range tests {
+		if got := inflections.Pluralize(tt.singular); got != tt.plural {
+			t.Errorf("Pluralize(%q) = %q, want %q", tt.singular, got, tt.plural)
+		}
+		if got := inflections.Singularize(tt.plural); got != tt.singular {
+			t.Errorf("Singularize(%q) = %q, want %q", tt.plural, got, tt.singular)
+		}
+	}
+}
+
+func TestInflections_Camelize(t *testing.T) {
+	inflections := New()
+	inflections.Acronym("API")
+	inflections.Acronym("HTML")
+	inflections.Acronym("RESTful")
+
+	tests := map[string]string{
+		"user":                        "User",
+		"line_item":                   "LineItem",
+		"billing/invoice":             "Billing::Invoice",
+		"api/v1/users_controller":     "API::V1::UsersController",
+		"html_parser":                 "HTMLParser",
+		"restful_controller":          "RESTfulController",
+		"admin/html_helper":           "Admin::HTMLHelper",
+		"oauth2_client":               "Oauth2Client",
+		"apis":                        "Apis",
+		"application_api_controller":  "ApplicationAPIController",
+		"v2/html/rendering_api_thing": "V2::HTML::RenderingAPIThing",
+	}
+	for word, expected := range tests {
+		if got := inflections.Camelize(word); got != expected {
+			t.Errorf("Camelize(%q) = %q, want %q", word, got, expected)
+		}
+		if got := inflections.Underscore(expected); got != word {
+			t.Errorf("Underscore(%q) = %q, want %q", expected, got, word)
+		}
+	}
+
+	// Without acronyms
+	defaults := New()
+	if got := defaults.Camelize("api/v1/users_controller"); got != "Api::V1::UsersController" {
+		t.Errorf("Camelize() = %q, want %q", got, "Api::V1::UsersController")
+	}
+	underscores := map[string]string{
+		"HTMLParser":      "html_parser",
+		"UserName":        "user_name",
+		"Api::V1::Users":  "api/v1/users",
+		"Version2Upgrade": "version2_upgrade",
+		"already_snake":   "already_snake",
+		"Dashed-Name":     "dashed_name",
+	}
+	for word, expected := range underscores {
+		if got := defaults.Underscore(word); got != expected {
+			t.Errorf("Underscore(%q) = %q, want %q", word, got, expected)
+		}
+	}
+}
+
+func TestInflections_Custom(t *testing.T) {
+	inflections := New()
+	inflections.Irregular("cactus", "cacti")
+	inflections.Uncountable("metadata")
+	if err := inflections.Plural(`(?i)(campu)s$`, "${1}ses"); err != nil {
+		t.Fatalf("Plural() error = %v", err)
+	}
+
+	tests := map[string]string{
+		"cactus":   "cacti",
+		"metadata": "metadata",
+		"campus":   "campuses",
+	}
+	for word, expected := range tests {
+		if got := inflections.Pluralize(word); got != expected {
+			t.Errorf("Pluralize(%q) = %q, want %q", word, got, expected)
+		}
+	}
+	if got := inflections.Singularize("cacti"); got != "cactus" {
+		t.Errorf("Singularize(%q) = %q, want %q", "cacti", got, "cactus")
+	}
+
+	// The defaults are not affected
+	if got := New().Pluralize("cactus"); got != "cactus" {
+		t.Errorf("Pluralize(%q) = %q with default inflections, want %q", "cactus", got, "cactus")
+	}
+}
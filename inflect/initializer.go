@@ -0,0 +1,312 @@
+package inflect
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// InitializerPath is where Rails apps declare their inflections, relative
+// to the application root
+const InitializerPath = "config/initializers/inflections.rb"
+
+// declarationRegex finds inflection declarations such as
+// `inflect.acronym "API"` within the inflections block
+var declarationRegex = regexp.MustCompile(`\b\w+\.(acronym|irregular|uncountable|plural|singular|clear)\b`)
+
+// rubyGroupRegex finds \1 style group references in Ruby replacements
+var rubyGroupRegex = regexp.MustCompile(`\\(\d)`)
+
+// LoadInitializer returns the default inflections with the declarations
+// of a Rails inflections initializer applied. A missing file yields the
+// defaults. Declarations that can't be translated are skipped and
+// reported in the error, along with the inflections that could be.
+func LoadInitializer(path string) (*Inflections, error) {
+	i := New()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return i, nil
+		}
+		return i, err
+	}
+	if err := i.ParseInitializer(string(data)); err != nil {
+		return i, fmt.Errorf("%s: %w", path, err)
+	}
+	return i, nil
+}
+
+// ParseInitializer applies the declarations of a Rails inflections
+// initializer:
+//
+//	ActiveSupport::Inflector.inflections(:en) do |inflect|
+//	  inflect.acronym "API"
+//	  inflect.irregular "cactus", "cacti"
+//	  inflect.uncountable %w[metadata]
+//	  inflect.plural /^(ox)$/i, '\1en'
+//	end
+//
+// Ruby regular expressions are translated to Go's syntax; those using
+// features Go lacks, such as lookarounds, are skipped and reported.
+func (i *Inflections) ParseInitializer(source string) error {
+	source = stripComments(source)
+
+	var errs []error
+	for _, loc := range declarationRegex.FindAllStringSubmatchIndex(source, -1) {
+		method := source[loc[2]:loc[3]]
+		args, err := parseArgs(source[loc[1]:])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", method, err))
+			continue
+		}
+		if err := i.declare(method, args); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", method, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// declare applies one declaration
+func (i *Inflections) declare(method string, args []rubyValue) error {
+	switch method {
+	case "clear":
+		i.Clear()
+		return nil
+	case "acronym":
+		words, err := stringArgs(args, 1)
+		if err != nil {
+			return err
+		}
+		i.Acronym(words[0])
+		return nil
+	case "irregular":
+		words, err := stringArgs(args, 2)
+		if err != nil {
+			return err
+		}
+		i.Irregular(words[0], words[1])
+		return nil
+	case "uncountable":
+		var words []string
+		for _, arg := range args {
+			if arg.regex {
+				return fmt.Errorf("expected words, got /%s/", arg.text)
+			}
+			words = append(words, arg.words...)
+		}
+		i.Uncountable(words...)
+		return nil
+	}
+
+	// plural and singular take a rule, a regexp or a string, and a replacement
+	if len(args) != 2 || args[1].regex || len(args[1].words) != 1 || len(args[0].words) > 1 {
+		return fmt.Errorf("expected a rule and a replacement")
+	}
+	pattern := regexp.QuoteMeta(args[0].text)
+	if args[0].regex {
+		pattern = translateRegex(args[0].text, args[0].flags)
+	}
+	replacement := rubyGroupRegex.ReplaceAllString(args[1].words[0], "$${$1}")
+	if method == "plural" {
+		return i.Plural(pattern, replacement)
+	}
+	return i.Singular(pattern, replacement)
+}
+
+// rubyValue is a literal argument: a regexp, or one or more words from a
+// string, an array of strings or a %w[] list
+type rubyValue struct {
+	regex bool
+	text  string
+	flags string
+	words []string
+}
+
+// parseArgs parses the literal arguments of a method call up to the end
+// of the statement, with or without parentheses
+func parseArgs(source string) ([]rubyValue, error) {
+	s := strings.TrimLeft(source, " \t")
+	parens := strings.HasPrefix(s, "(")
+	if parens {
+		s = s[1:]
+	}
+
+	var args []rubyValue
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if parens {
+			s = strings.TrimLeft(s, " \t\r\n")
+		}
+		if s == "" || s[0] == '\n' || s[0] == ';' || s[0] == ')' {
+			return args, nil
+		}
+
+		value, rest, err := parseValue(s)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, value)
+
+		s = strings.TrimLeft(rest, " \t")
+		if !strings.HasPrefix(s, ",") {
+			return args, nil
+		}
+		// Arguments may continue on the next line after a comma
+		s = strings.TrimLeft(s[1:], " \t\r\n")
+	}
+}
+
+// parseValue parses one literal at the start of s and returns the rest
+func parseValue(s string) (rubyValue, string, error) {
+	switch {
+	case s[0] == '"' || s[0] == '\'':
+		text, rest, err := parseString(s)
+		return rubyValue{text: text, words: []string{text}}, rest, err
+	case s[0] == '/':
+		end := closingIndex(s, 1, '/')
+		if end < 0 {
+			return rubyValue{}, "", fmt.Errorf("unterminated regexp")
+		}
+		rest := s[end+1:]
+		flags := rest[:len(rest)-len(strings.TrimLeft(rest, "imxo"))]
+		return rubyValue{regex: true, text: s[1:end], flags: flags}, rest[len(flags):], nil
+	case strings.HasPrefix(s, "%w") || strings.HasPrefix(s, "%i"):
+		if len(s) < 3 {
+			return rubyValue{}, "", fmt.Errorf("unterminated word list")
+		}
+		closing := map[byte]byte{'(': ')', '[': ']', '{': '}', '<': '>'}[s[2]]
+		if closing == 0 {
+			closing = s[2]
+		}
+		end := strings.IndexByte(s[3:], closing)
+		if end < 0 {
+			return rubyValue{}, "", fmt.Errorf("unterminated word list")
+		}
+		words := strings.Fields(s[3 : 3+end])
+		return rubyValue{text: strings.Join(words, " "), words: words}, s[3+end+1:], nil
+	case s[0] == '[':
+		value := rubyValue{}
+		s = s[1:]
+		for {
+			s = strings.TrimLeft(s, " \t\r\n,")
+			if strings.HasPrefix(s, "]") {
+				value.text = strings.Join(value.words, " ")
+				return value, s[1:], nil
+			}
+			if s == "" || (s[0] != '"' && s[0] != '\'') {
+				return rubyValue{}, "", fmt.Errorf("expected an array of strings")
+			}
+			word, rest, err := parseString(s)
+			if err != nil {
+				return rubyValue{}, "", err
+			}
+			value.words = append(value.words, word)
+			s = rest
+		}
+	}
+	return rubyValue{}, "", fmt.Errorf("unsupported argument %q", firstLine(s))
+}
+
+// parseString parses a single or double quoted string, interpreting the
+// escapes of each: \\ and \' in single quotes, \\ and \" in double quotes
+// (other escapes are kept as written)
+func parseString(s string) (string, string, error) {
+	quote := s[0]
+	end := closingIndex(s, 1, quote)
+	if end < 0 {
+		return "", "", fmt.Errorf("unterminated string")
+	}
+	body := s[1:end]
+
+	var b strings.Builder
+	for n := 0; n < len(body); n++ {
+		if body[n] == '\\' && n+1 < len(body) && (body[n+1] == '\\' || body[n+1] == quote) {
+			n++
+		}
+		b.WriteByte(body[n])
+	}
+	return b.String(), s[end+1:], nil
+}
+
+// closingIndex returns the index of the first unescaped delimiter at or
+// after start, or -1
+func closingIndex(s string, start int, delimiter byte) int {
+	for n := start; n < len(s); n++ {
+		switch s[n] {
+		case '\\':
+			n++
+		case delimiter:
+			return n
+		case '\n':
+			return -1
+		}
+	}
+	return -1
+}
+
+// translateRegex converts a Ruby regexp literal to Go syntax. \A, \z and
+// \h translate directly; \Z becomes \z.
+func translateRegex(pattern, flags string) string {
+	pattern = strings.ReplaceAll(pattern, `\Z`, `\z`)
+	pattern = strings.ReplaceAll(pattern, `\h`, `[0-9a-fA-F]`)
+	var goFlags string
+	if strings.Contains(flags, "i") {
+		goFlags += "i"
+	}
+	if strings.Contains(flags, "m") {
+		// Ruby's multiline mode lets . match newlines
+		goFlags += "s"
+	}
+	if goFlags != "" {
+		return "(?" + goFlags + ")" + pattern
+	}
+	return pattern
+}
+
+// stringArgs returns the words of exactly n string arguments
+func stringArgs(args []rubyValue, n int) ([]string, error) {
+	var words []string
+	for _, arg := range args {
+		if arg.regex || len(arg.words) != 1 {
+			return nil, fmt.Errorf("expected %d string arguments", n)
+		}
+		words = append(words, arg.words[0])
+	}
+	if len(words) != n {
+		return nil, fmt.Errorf("expected %d string arguments", n)
+	}
+	return words, nil
+}
+
+// stripComments removes Ruby line comments, leaving # inside strings,
+// regexps and interpolations alone
+func stripComments(source string) string {
+	lines := strings.Split(source, "\n")
+	for n, line := range lines {
+		var quote byte
+		for c := 0; c < len(line); c++ {
+			switch {
+			case quote != 0 && line[c] == '\\':
+				c++
+			case quote != 0 && line[c] == quote:
+				quote = 0
+			case quote == 0 && (line[c] == '"' || line[c] == '\'' || line[c] == '/'):
+				quote = line[c]
+			case quote == 0 && line[c] == '#':
+				lines[n] = line[:c]
+				c = len(line)
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// firstLine returns s up to its first newline
+func firstLine(s string) string {
+	if n := strings.IndexByte(s, '\n'); n >= 0 {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,97 @@
+package inflect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const initializer = `# Be sure to restart your server when you modify this file.
+
+ActiveSupport::Inflector.inflections(:en) do |inflect|
+  inflect.acronym "API"
+  inflect.acronym 'HTML' # markup
+  inflect.irregular "cactus", "cacti"
+  inflect.uncountable %w[metadata feedback]
+  inflect.uncountable(["sheep", "fish"])
+  inflect.plural(/^(ox)$/i,
+                 '\1en')
+  inflect.singular /^(ox)en$/i, '\1'
+  # inflect.acronym "REST"
+end
+`
+
+func TestInflections_ParseInitializer(t *testing.T) {
+	i := New()
+	if err := i.ParseInitializer(initializer); err != nil {
+		t.Fatalf("ParseInitializer() error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		fn       func(string) string
+		word     string
+		expected string
+	}{
+		{"camelize acronym", i.Camelize, "api/v1/users_controller", "API::V1::UsersController"},
+		{"camelize quoted acronym", i.Camelize, "html_parser", "HTMLParser"},
+		{"commented acronym", i.Camelize, "rest_client", "RestClient"},
+		{"underscore acronym", i.Underscore, "API::V1::HTMLParser", "api/v1/html_parser"},
+		{"irregular", i.Pluralize, "cactus", "cacti"},
+		{"irregular back", i.Singularize, "cacti", "cactus"},
+		{"uncountable word list", i.Pluralize, "feedback", "feedback"},
+		{"uncountable array", i.Pluralize, "fish", "fish"},
+		{"multiline plural", i.Pluralize, "Ox", "Oxen"},
+		{"singular", i.Singularize, "oxen", "ox"},
+	}
+	for _, tt := range tests {
+		if got := tt.fn(tt.word); got != tt.expected {
+			t.Errorf("%s: %q = %q, want %q", tt.name, tt.word, got, tt.expected)
+		}
+	}
+}
+
+func TestInflections_ParseInitializerErrors(t *testing.T) {
+	i := New()
+	err := i.ParseInitializer(`ActiveSupport::Inflector.inflections do |inflect|
+  inflect.plural /(?<=x)y$/, 'ies'
+  inflect.acronym "API"
+  inflect.irregular "person"
+end
+`)
+	if err == nil {
+		t.Fatal("ParseInitializer() error = nil, want errors for the lookbehind and the irregular")
+	}
+	if !strings.Contains(err.Error(), "plural") || !strings.Contains(err.Error(), "irregular") {
+		t.Errorf("ParseInitializer() error = %v, want plural and irregular errors", err)
+	}
+	// Translatable declarations still apply
+	if got := i.Camelize("api"); got != "API" {
+		t.Errorf("Camelize(%q) = %q, want %q", "api", got, "API")
+	}
+}
+
+func TestLoadInitializer(t *testing.T) {
+	dir := t.TempDir()
+
+	i, err := LoadInitializer(filepath.Join(dir, InitializerPath))
+	if err != nil {
+		t.Fatalf("LoadInitializer() of a missing file: %v", err)
+	}
+	if got := i.Pluralize("person"); got != "people" {
+		t.Errorf("Pluralize(%q) = %q, want the defaults", "person", got)
+	}
+
+	path := filepath.Join(dir, "inflections.rb")
+	if err := os.WriteFile(path, []byte(initializer), 0644); err != nil {
+		t.Fatal(err)
+	}
+	i, err = LoadInitializer(path)
+	if err != nil {
+		t.Fatalf("LoadInitializer() error: %v", err)
+	}
+	if got := i.Camelize("api"); got != "API" {
+		t.Errorf("Camelize(%q) = %q, want %q", "api", got, "API")
+	}
+}
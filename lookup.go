@@ -0,0 +1,82 @@
+package main
+
+import (
+	"path/filepath"
+)
+
+// Project kinds reported by Project.Kind
+const (
+	KindGem   = "gem"
+	KindRails = "rails"
+	KindRuby  = "ruby"
+)
+
+// Kind returns the detected project type: gem, rails or plain ruby
+func (p *Project) Kind() string {
+	if p.IsGem() {
+		return KindGem
+	}
+	if fileExists(filepath.Join(p.Root, "config", "application.rb")) {
+		return KindRails
+	}
+	return KindRuby
+}
+
+// LookupResult describes the outcome of resolving a file's alternate:
+// the first existing candidate, every existing candidate, and all those
+// tried. When no candidate exists, Alternates lists the files found
+// through the described constant instead. Without any, Matches ranks the
+// files found by the fuzzy search and Alternate is the confident match,
+// if any. Line is where to open the alternate when looking up from a
+// line. All paths are absolute.
+type LookupResult struct {
+	Source      string       `json:"source"`
+	Alternate   string       `json:"alternate"`
+	Alternates  []string     `json:"alternates"`
+	Candidates  []string     `json:"candidates"`
+	Matches     []FuzzyMatch `json:"matches"`
+	ProjectType string       `json:"project_type"`
+	Framework   Framework    `json:"framework"`
+	Line        int          `json:"line,omitempty"`
+}
+
+// Lookup resolves the alternate file and reports how it was found
+func (s *SourceFile) Lookup() LookupResult {
+	candidates := []string{}
+	alternates := []string{}
+	for _, candidate := range s.Candidates() {
+		candidate = filepath.Join(s.Project.Root, candidate)
+		candidates = append(candidates, candidate)
+		if fileExists(candidate) {
+			alternates = append(alternates, candidate)
+		}
+	}
+	if len(alternates) == 0 {
+		for _, alternate := range s.ConstantAlternates() {
+			alternates = append(alternates, filepath.Join(s.Project.Root, alternate))
+		}
+	}
+
+	result := LookupResult{
+		Source:      filepath.Join(s.Project.Root, s.Filename),
+		Alternate:   s.AlternateFile(),
+		Alternates:  alternates,
+		Candidates:  candidates,
+		Matches:     []FuzzyMatch{},
+		ProjectType: s.Project.Kind(),
+		Framework:   s.Project.Framework(),
+	}
+	if result.Alternate != "" {
+		return result
+	}
+
+	// Fall back to searching the project
+	for _, match := range s.FuzzyMatches() {
+		match.Path = filepath.Join(s.Project.Root, match.Path)
+		result.Matches = append(result.Matches, match)
+	}
+	if match := s.Project.ConfidentMatch(result.Matches); match != "" {
+		result.Alternate = match
+	}
+	return result
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestProject_Kind(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []string
+		expected string
+	}{
+		{name: "gem", files: []string{"my_gem.gemspec"}, expected: KindGem},
+		{name: "rails", files: []string{"config/application.rb"}, expected: KindRails},
+		{name: "plain ruby", files: nil, expected: KindRuby},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, file := range tt.files {
+				writeFile(t, filepath.Join(dir, file), "")
+			}
+			if got := NewProject(dir).Kind(); got != tt.expected {
+				t.Errorf("Kind() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSourceFile_Candidates(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".rspec"), "")
+	project := NewProject(dir)
+
+	got := NewSourceFile("app/models/user.rb", project).Candidates()
+	expected := []string{
+		"spec/models/user_spec.rb",
+		"spec/lib/models/user_spec.rb",
+	}
+	if !equalStrings(got, expected) {
+		t.Errorf("Candidates() = %v, want %v", got, expected)
+	}
+}
+
+func TestSourceFile_Lookup(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".rspec"), "")
+	writeFile(t, filepath.Join(dir, "config", "application.rb"), "")
+	writeFile(t, filepath.Join(dir, "app", "models", "user.rb"), "")
+	writeFile(t, filepath.Join(dir, "spec", "models", "user_spec.rb"), "")
+
+	result := NewSourceFile("app/models/user.rb", NewProject(dir)).Lookup()
+
+	if result.Source != filepath.Join(dir, "app/models/user.rb") {
+		t.Errorf("Source = %q", result.Source)
+	}
+	if result.Alternate != filepath.Join(dir, "spec/models/user_spec.rb") {
+		t.Errorf("Alternate = %q", result.Alternate)
+	}
+	if len(result.Candidates) == 0 || result.Candidates[0] != result.Alternate {
+		t.Errorf("Candidates = %v, want the alternate first", result.Candidates)
+	}
+	if result.ProjectType != KindRails {
+		t.Errorf("ProjectType = %q, want %q", result.ProjectType, KindRails)
+	}
+	if result.Framework != RSpec {
+		t.Errorf("Framework = %q, want %q", result.Framework, RSpec)
+	}
+}
@@ -1,14 +1,21 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
 	"log"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
+
+	"github.com/stephen/go-zed-test-toggle/inflect"
 )
 
 // Version information (set at build time)
@@ -20,57 +27,196 @@ var (
 
 // Project represents a Ruby/Rails project structure
 type Project struct {
-	Root string
+	Root        string
+	Config      *Config
+	Projections []Projection
+	ConfigErr   error
+	// Parent is the enclosing project when this one is a package within it
+	Parent *Project
+	// clues caches the framework detection, see detect
+	clues *frameworkClues
+	// framework restricts the project to one framework, see frameworkViews
+	framework Framework
+	// inflections caches the inflection rules, see Inflections
+	inflections *inflect.Inflections
+	// described caches the test files by the constant they describe, see
+	// describedTests
+	described map[string][]string
+	// packages caches the package of each directory, nil for directories
+	// that aren't packages, see Package
+	packages map[string]*Project
+}
+
+// frameworkClues are the files marking the project's test framework
+type frameworkClues struct {
+	spec        string
+	minitest    string
+	defaultPath string
 }
 
+// specHelperMaxDepth bounds how many directories below the root the
+// search for a nested spec/spec_helper.rb descends
+const specHelperMaxDepth = 4
+
 // NewProject creates a new Project instance
 func NewProject(root string) *Project {
 	// Remove trailing slash
 	root = strings.TrimSuffix(root, "/")
-	return &Project{Root: root}
+	config, err := LoadConfig(root)
+	projections, projectionsErr := LoadProjections(root)
+	if err == nil {
+		err = projectionsErr
+	}
+	return &Project{Root: root, Config: config, Projections: projections, ConfigErr: err}
+}
+
+// config returns the project configuration, never nil
+func (p *Project) config() *Config {
+	if p.Config == nil {
+		return &Config{}
+	}
+	return p.Config
 }
 
 // IsGem checks if the project is a gem
 func (p *Project) IsGem() bool {
+	return p.GemClue() != ""
+}
+
+// GemClue returns the gemspec that marks the project as a gem, or ""
+func (p *Project) GemClue() string {
 	pattern := filepath.Join(p.Root, "*.gemspec")
 	matches, err := filepath.Glob(pattern)
-	if err != nil {
-		return false
+	if err != nil || len(matches) == 0 {
+		return ""
 	}
-	return len(matches) > 0
+	return matches[0]
 }
 
-// IsSpec checks if the project uses RSpec
+// IsEngine checks if the project is a Rails engine
+func (p *Project) IsEngine() bool {
+	matches, err := filepath.Glob(filepath.Join(p.Root, "lib", "*", "engine.rb"))
+	return err == nil && len(matches) > 0
+}
+
+// IsSpec checks if the project's preferred framework is RSpec
 func (p *Project) IsSpec() bool {
-	specClues := []string{
+	return p.Framework() == RSpec
+}
+
+// MinitestClue returns the file or directory that marks the project as
+// using Minitest, or ""
+func (p *Project) MinitestClue() string {
+	return p.detect().minitest
+}
+
+// SpecClue returns the file that marks the project as using RSpec, or ""
+func (p *Project) SpecClue() string {
+	return p.detect().spec
+}
+
+// detect finds the framework clues once and caches them
+func (p *Project) detect() *frameworkClues {
+	if p.clues != nil {
+		return p.clues
+	}
+	clues := &frameworkClues{}
+	p.clues = clues
+
+	helper := filepath.Join(p.Root, "test", "test_helper.rb")
+	testDir := filepath.Join(p.Root, "test")
+	if fileExists(helper) {
+		clues.minitest = helper
+	} else if fileExists(testDir) && !fileExists(filepath.Join(p.Root, "spec")) {
+		clues.minitest = testDir
+	}
+
+	dotRSpec := filepath.Join(p.Root, ".rspec")
+	if fileExists(dotRSpec) {
+		clues.defaultPath = rspecDefaultPath(dotRSpec)
+	}
+	for _, clue := range []string{
 		filepath.Join(p.Root, "spec", "spec_helper.rb"),
-		filepath.Join(p.Root, ".rspec"),
-		filepath.Join(p.Root, "**", "spec", "spec_helper.rb"),
+		filepath.Join(p.Root, "spec", "rails_helper.rb"),
+		dotRSpec,
+	} {
+		if fileExists(clue) {
+			clues.spec = clue
+			return clues
+		}
 	}
 
-	for _, clue := range specClues {
-		matches, err := filepath.Glob(clue)
+	// A spec helper further down only counts when nothing says Minitest
+	if clues.minitest == "" {
+		clues.spec = findSpecHelper(p.Root)
+	}
+	return clues
+}
+
+// findSpecHelper searches below the root for a spec/spec_helper.rb,
+// skipping dependency and build directories, and returns the first found
+func findSpecHelper(root string) string {
+	found := ""
+	_ = filepath.WalkDir(root, func(file string, d fs.DirEntry, err error) error {
 		if err != nil {
-			continue
+			return nil
 		}
-		if len(matches) > 0 {
-			return true
+		if d.IsDir() {
+			if file == root {
+				return nil
+			}
+			rel, _ := filepath.Rel(root, file)
+			if skippedDirs[d.Name()] || strings.Count(filepath.ToSlash(rel), "/") >= specHelperMaxDepth {
+				return filepath.SkipDir
+			}
+			return nil
 		}
+		if d.Name() == "spec_helper.rb" && filepath.Base(filepath.Dir(file)) == "spec" {
+			found = file
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return found
+}
+
+// rspecDefaultPath returns the --default-path option set in a .rspec
+// file, or ""
+func rspecDefaultPath(dotRSpec string) string {
+	data, err := os.ReadFile(dotRSpec)
+	if err != nil {
+		return ""
 	}
-	return false
+	fields := strings.Fields(string(data))
+	for i, field := range fields {
+		if value, ok := strings.CutPrefix(field, "--default-path="); ok {
+			return strings.Trim(value, `"'`)
+		}
+		if field == "--default-path" && i+1 < len(fields) {
+			return strings.Trim(fields[i+1], `"'`)
+		}
+	}
+	return ""
 }
 
 // SrcPaths returns the source paths for the project
 func (p *Project) SrcPaths() []string {
-	if p.IsGem() {
+	if paths := p.config().SrcPaths; len(paths) > 0 {
+		return paths
+	}
+	if p.IsGem() && !p.IsEngine() {
 		return []string{"lib", ""}
 	}
 	return []string{"app", "lib"}
 }
 
-// TestAnchor returns the test directory name
+// TestAnchor returns the test directory name. RSpec projects may move it
+// with --default-path in .rspec.
 func (p *Project) TestAnchor() string {
 	if p.IsSpec() {
+		if defaultPath := p.detect().defaultPath; defaultPath != "" {
+			return path.Clean(filepath.ToSlash(defaultPath))
+		}
 		return "spec"
 	}
 	return "test"
@@ -78,34 +224,141 @@ func (p *Project) TestAnchor() string {
 
 // TestPaths returns the test paths for the project
 func (p *Project) TestPaths() []string {
+	if paths := p.config().TestPaths; len(paths) > 0 {
+		return paths
+	}
 	anchor := p.TestAnchor()
 	return []string{anchor, filepath.Join(anchor, "lib")}
 }
 
-// TestRegexes returns the regexes for matching test files
-func (p *Project) TestRegexes() []*regexp.Regexp {
+// Test file naming styles, see Config.TestNaming
+const (
+	// NamingSuffix appends a suffix to the source name, e.g. user_test.rb
+	NamingSuffix = "suffix"
+	// NamingPrefix puts a prefix before the source name, e.g. test_user.rb
+	NamingPrefix = "prefix"
+)
+
+// testSupportFiles are helpers that look like tests but have no source
+var testSupportFiles = map[string]bool{
+	"test_helper.rb": true,
+}
+
+// hasNamingConfig reports whether the configuration overrides the test
+// file suffixes or prefixes, replacing the defaults entirely
+func (p *Project) hasNamingConfig() bool {
+	config := p.config()
+	return len(config.TestSuffixes) > 0 || len(config.TestPrefixes) > 0
+}
+
+// TestSuffixes returns the test file name suffixes
+func (p *Project) TestSuffixes() []string {
+	if p.hasNamingConfig() {
+		return p.config().TestSuffixes
+	}
 	if p.IsSpec() {
-		return []*regexp.Regexp{
-			regexp.MustCompile(`_spec\.rb$`),
-		}
+		return []string{"_spec.rb"}
 	}
-	return []*regexp.Regexp{
-		regexp.MustCompile(`_test\.rb$`),
-		regexp.MustCompile(`test_[a-zA-Z0-9_]*\.rb$`),
+	return []string{"_test.rb"}
+}
+
+// TestPrefixes returns the test file name prefixes. Minitest projects
+// default to test_ and test-unit's tc_.
+func (p *Project) TestPrefixes() []string {
+	if p.hasNamingConfig() {
+		return p.config().TestPrefixes
+	}
+	if p.IsSpec() {
+		return nil
+	}
+	return []string{"test_", "tc_"}
+}
+
+// TestRegexes returns the regexes for matching test file names
+func (p *Project) TestRegexes() []*regexp.Regexp {
+	var regexes []*regexp.Regexp
+	for _, suffix := range p.TestSuffixes() {
+		regexes = append(regexes, regexp.MustCompile(regexp.QuoteMeta(suffix)+`$`))
+	}
+	for _, prefix := range p.TestPrefixes() {
+		regexes = append(regexes, regexp.MustCompile(`(^|/)`+regexp.QuoteMeta(prefix)+`[^/]+\.rb$`))
+	}
+	return regexes
+}
+
+// TestNaming returns the naming style used for new test files: the
+// configured one, otherwise prefix when only prefixes are configured,
+// otherwise suffix
+func (p *Project) TestNaming() string {
+	if naming := p.config().TestNaming; naming != "" {
+		return naming
 	}
+	if len(p.TestSuffixes()) == 0 && len(p.TestPrefixes()) > 0 {
+		return NamingPrefix
+	}
+	return NamingSuffix
 }
 
 // TestSuffix returns the test file suffix
 func (p *Project) TestSuffix() string {
+	if suffixes := p.config().TestSuffixes; len(suffixes) > 0 {
+		return suffixes[0]
+	}
 	if p.IsSpec() {
 		return "_spec.rb"
 	}
 	return "_test.rb"
 }
 
-// Testify converts a source file path to a test file path
-func (p *Project) Testify(path string) string {
-	return strings.Replace(path, ".rb", p.TestSuffix(), 1)
+// TestPrefix returns the test file prefix
+func (p *Project) TestPrefix() string {
+	if prefixes := p.TestPrefixes(); len(prefixes) > 0 {
+		return prefixes[0]
+	}
+	return "test_"
+}
+
+// Testify converts a source file path to a test file path in the
+// project's naming style: its trailing .rb extension is replaced with the
+// test suffix, or its base name is given the test prefix. View templates
+// keep their extensions and get the suffix, as in show.html.erb_spec.rb.
+func (p *Project) Testify(file string) string {
+	if isTemplate(file) {
+		return file + p.TestSuffix()
+	}
+	if !strings.HasSuffix(file, ".rb") {
+		return file
+	}
+	if p.TestNaming() == NamingPrefix {
+		dir, base := path.Split(file)
+		return dir + p.TestPrefix() + base
+	}
+	return strings.TrimSuffix(file, ".rb") + p.TestSuffix()
+}
+
+// Untestify converts a test file path to its source file path by
+// removing the test suffix or prefix from the base name. It reports
+// whether the path names a test file in any of the project's styles.
+func (p *Project) Untestify(file string) (string, bool) {
+	dir, base := path.Split(file)
+	if testSupportFiles[base] {
+		return "", false
+	}
+	for _, suffix := range p.TestSuffixes() {
+		if strings.HasSuffix(base, suffix) && len(base) > len(suffix) {
+			name := strings.TrimSuffix(base, suffix)
+			if !isTemplate(name) {
+				name += ".rb"
+			}
+			return dir + name, true
+		}
+	}
+	for _, prefix := range p.TestPrefixes() {
+		if strings.HasPrefix(base, prefix) && strings.HasSuffix(base, ".rb") && len(base) > len(prefix)+len(".rb") {
+			return dir + strings.TrimPrefix(base, prefix), true
+		}
+	}
+	return "", false
 }
 
 // SourceFile represents a source or test file
@@ -124,76 +377,116 @@ func NewSourceFile(filename string, project *Project) *SourceFile {
 
 // IsTestFile checks if the file is a test file
 func (s *SourceFile) IsTestFile() bool {
-	for _, regex := range s.Project.TestRegexes() {
-		if regex.MatchString(s.Filename) {
+	if scoped, dir := s.scoped(); dir != "" {
+		return scoped.IsTestFile()
+	}
+	return s.Project.isTestName(s.Filename)
+}
+
+// isTestName reports whether the file is named like a test of one of the
+// project's frameworks, leaving packages aside
+func (p *Project) isTestName(file string) bool {
+	for _, project := range p.frameworkViews() {
+		if _, ok := project.Untestify(file); ok {
 			return true
 		}
 	}
 	return false
 }
 
+// allTestPaths returns the test paths of each framework of the project
+func (p *Project) allTestPaths() []string {
+	var paths []string
+	for _, project := range p.frameworkViews() {
+		paths = append(paths, project.TestPaths()...)
+	}
+	return uniqueCandidates(paths)
+}
+
 // IsController checks if the file is a Rails controller
 func (s *SourceFile) IsController() bool {
-	return strings.Contains(s.Filename, "app/controllers/") && strings.HasSuffix(s.Filename, "_controller.rb")
+	return strings.HasPrefix(s.Filename, "app/controllers/") && strings.HasSuffix(s.Filename, "_controller.rb")
 }
 
-// IsRequestSpec checks if the file is a Rails request spec
+// IsRequestSpec checks if the file is a Rails request spec, a test in the
+// requests directory of the test path, e.g. spec/requests/users_spec.rb
 func (s *SourceFile) IsRequestSpec() bool {
-	return strings.Contains(s.Filename, "spec/requests/") && strings.HasSuffix(s.Filename, "_controller_spec.rb")
+	source, ok := s.Project.Untestify(s.Filename)
+	return ok && strings.HasPrefix(source, path.Join(s.Project.TestAnchor(), "requests")+"/")
 }
 
-// AlternateFile finds the alternate file (test->source or source->test)
+// AlternateFile finds the alternate file (test->source or source->test),
+// falling back to the constant the test describes when no candidate
+// exists
 func (s *SourceFile) AlternateFile() string {
-	if s.IsTestFile() {
-		return s.findAlternateSrc()
+	if alternate := s.firstExisting(s.Candidates()); alternate != "" {
+		return alternate
 	}
-	return s.findAlternateTest()
+	return s.firstExisting(s.ConstantAlternates())
 }
 
-// findAlternateSrc finds the source file for a test file
-func (s *SourceFile) findAlternateSrc() string {
-	// Special handling for request specs with _controller suffix
-	if s.IsRequestSpec() {
-		candidate := strings.Replace(s.Filename, "spec/requests/", "app/controllers/", 1)
-		candidate = strings.Replace(candidate, "_controller_spec.rb", "_controller.rb", 1)
-		target := filepath.Join(s.Project.Root, candidate)
-		if fileExists(target) {
-			return target
+// Candidates returns every alternate path tried for the file, relative to
+// the project root and in the order they are tried: those of the package
+// owning the file first, then the project's own source or test mapping
+// for each of its frameworks, the preferred one first
+func (s *SourceFile) Candidates() []string {
+	candidates := s.packageCandidates()
+	isTest := s.IsTestFile()
+	for _, project := range s.Project.frameworkViews() {
+		file := NewSourceFile(s.Filename, project)
+		switch {
+		case isTest:
+			candidates = append(candidates, file.srcCandidates()...)
+		case file.IsFactory():
+			// Factories toggle to their model
+			candidates = append(candidates, file.projectedAlternates()...)
+			candidates = append(candidates, file.modelCandidates()...)
+		default:
+			candidates = append(candidates, file.testCandidates()...)
 		}
 	}
 
-	srcPaths := s.Project.SrcPaths()
-	testPaths := s.Project.TestPaths()
-	testRegexes := s.Project.TestRegexes()
-
-	for _, srcPath := range srcPaths {
-		for _, testPath := range testPaths {
-			for _, regex := range testRegexes {
-				// Replace test path with src path
-				candidate := strings.Replace(s.Filename, testPath, srcPath, 1)
-				// Replace test suffix with .rb
-				candidate = regex.ReplaceAllString(candidate, ".rb")
-
-				target := filepath.Join(s.Project.Root, candidate)
-				if fileExists(target) {
-					return target
-				}
+	var alternates []string
+	for _, candidate := range uniqueCandidates(candidates) {
+		// A mapping that doesn't apply leaves the path unchanged
+		if candidate != filepath.Clean(s.Filename) {
+			alternates = append(alternates, candidate)
+		}
+	}
+	return alternates
+}
+
+// srcCandidates lists the possible source paths for a test file
+func (s *SourceFile) srcCandidates() []string {
+	candidates := s.projectedAlternates()
+
+	// Request specs and integration tests cover a controller
+	candidates = append(candidates, s.controllerCandidates()...)
+
+	// Remove the test suffix or prefix
+	source, ok := s.Project.Untestify(s.Filename)
+	if !ok {
+		return uniqueCandidates(candidates)
+	}
+
+	for _, srcPath := range s.Project.SrcPaths() {
+		for _, testPath := range s.Project.TestPaths() {
+			// Replace test path with src path
+			if candidate, ok := replacePathPrefix(source, testPath, srcPath); ok {
+				candidates = append(candidates, candidate)
 			}
 		}
 	}
-	return ""
+	return uniqueCandidates(candidates)
 }
 
-// findAlternateTest finds the test file for a source file
-func (s *SourceFile) findAlternateTest() string {
-	// Special handling for controllers -> request specs
+// testCandidates lists the possible test paths for a source file
+func (s *SourceFile) testCandidates() []string {
+	candidates := s.projectedAlternates()
+
+	// Controllers map to request, controller or integration tests
 	if s.IsController() {
-		candidate := strings.Replace(s.Filename, "app/controllers/", "spec/requests/", 1)
-		candidate = strings.Replace(candidate, "_controller.rb", "_controller_spec.rb", 1)
-		target := filepath.Join(s.Project.Root, candidate)
-		if fileExists(target) {
-			return target
-		}
+		candidates = append(candidates, s.controllerTestCandidates()...)
 	}
 
 	testPaths := s.Project.TestPaths()
@@ -201,26 +494,63 @@ func (s *SourceFile) findAlternateTest() string {
 
 	for _, testPath := range testPaths {
 		for _, srcPath := range srcPaths {
-			var candidate string
-			if srcPath == "" {
-				// For empty src path (gem root files), prepend test path
-				candidate = filepath.Join(testPath, s.Filename)
-			} else {
-				// Replace src path with test path
-				candidate = strings.Replace(s.Filename, srcPath, testPath, 1)
+			// Replace src path with test path; an empty src path (gem
+			// root files) matches any file
+			candidate, ok := replacePathPrefix(s.Filename, srcPath, testPath)
+			if !ok {
+				continue
 			}
 			// Convert to test file name
-			candidate = s.Project.Testify(candidate)
+			candidates = append(candidates, s.Project.Testify(candidate))
+		}
+	}
+	return uniqueCandidates(candidates)
+}
 
-			target := filepath.Join(s.Project.Root, candidate)
-			if fileExists(target) {
-				return target
-			}
+// firstExisting returns the absolute path of the first candidate that
+// exists, or "" if none does
+func (s *SourceFile) firstExisting(candidates []string) string {
+	for _, candidate := range candidates {
+		target := filepath.Join(s.Project.Root, candidate)
+		if fileExists(target) {
+			return target
 		}
 	}
 	return ""
 }
 
+// replacePathPrefix replaces the leading directory from of a slash
+// separated path with to, matching whole path segments only. An empty
+// from matches any path. It reports whether the path starts with from.
+func replacePathPrefix(p, from, to string) (string, bool) {
+	rest := p
+	if from != "" {
+		if !strings.HasPrefix(p, from+"/") {
+			return "", false
+		}
+		rest = strings.TrimPrefix(p, from+"/")
+	}
+	return path.Join(to, rest), true
+}
+
+// uniqueCandidates removes duplicate and empty candidates, keeping order
+func uniqueCandidates(candidates []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, candidate := range candidates {
+		if candidate == "" {
+			continue
+		}
+		candidate = filepath.Clean(candidate)
+		if seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+		unique = append(unique, candidate)
+	}
+	return unique
+}
+
 // fileExists checks if a file exists
 func fileExists(path string) bool {
 	_, err := os.Stat(path)
@@ -229,20 +559,25 @@ func fileExists(path string) bool {
 
 // CLI handles command line interface
 type CLI struct {
-	Root string
-	Path string
+	Command string
+	Root    string
+	Path    string
+	Create  bool
+	Line    int
+	Editor  string
+	Print   bool
+	Format  string
+	Verbose bool
+	Limits  Thresholds
+	// CacheDir keeps state between invocations (default: the user cache dir)
+	CacheDir string
+	Stdout   io.Writer
+	Stderr   io.Writer
 }
 
 // NewCLI creates a new CLI instance from command line arguments
 func NewCLI() *CLI {
-	cli := &CLI{}
-
-	// Define the lookup command
-	lookupCmd := flag.NewFlagSet("lookup", flag.ExitOnError)
-	lookupCmd.StringVar(&cli.Root, "r", "", "Project root directory")
-	lookupCmd.StringVar(&cli.Root, "root", "", "Project root directory")
-	lookupCmd.StringVar(&cli.Path, "p", "", "Path to file")
-	lookupCmd.StringVar(&cli.Path, "path", "", "Path to file")
+	cli := &CLI{Stdout: os.Stdout, Stderr: os.Stderr}
 
 	// Check if we have a subcommand
 	if len(os.Args) < 2 {
@@ -259,7 +594,47 @@ func NewCLI() *CLI {
 	// Parse the subcommand
 	switch os.Args[1] {
 	case "lookup":
+		lookupCmd := cli.newFlagSet("lookup")
+		lookupCmd.BoolVar(&cli.Create, "create", false, "Create the test file when none exists")
+		lookupCmd.StringVar(&cli.Editor, "editor", "", "Editor used to open the file")
+		lookupCmd.BoolVar(&cli.Print, "print", false, "Print the alternate path instead of opening it")
+		lookupCmd.StringVar(&cli.Format, "format", "", "Print the lookup result in this format instead of opening it (json)")
+		lookupCmd.BoolVar(&cli.Verbose, "verbose", false, "Trace the resolution process on stderr")
+		lookupCmd.IntVar(&cli.Line, "line", 0, "Open the alternate at the method or describe block matching this line")
 		lookupCmd.Parse(os.Args[2:])
+	case "cycle":
+		cycleCmd := cli.newFlagSet("cycle")
+		cycleCmd.StringVar(&cli.Editor, "editor", "", "Editor used to open the file")
+		cycleCmd.BoolVar(&cli.Print, "print", false, "Print the next related path instead of opening it")
+		cycleCmd.Parse(os.Args[2:])
+	case "factory":
+		factoryCmd := cli.newFlagSet("factory")
+		factoryCmd.StringVar(&cli.Editor, "editor", "", "Editor used to open the file")
+		factoryCmd.BoolVar(&cli.Print, "print", false, "Print the factory path instead of opening it")
+		factoryCmd.Parse(os.Args[2:])
+	case "sync":
+		syncCmd := cli.newFlagSet("sync")
+		syncCmd.StringVar(&cli.Editor, "editor", "", "Editor used to open the file")
+		syncCmd.BoolVar(&cli.Print, "print", false, "Print the test path instead of opening it")
+		syncCmd.Parse(os.Args[2:])
+	case "explain":
+		cli.newFlagSet("explain").Parse(os.Args[2:])
+	case "doctor":
+		doctorCmd := cli.newFlagSet("doctor")
+		doctorCmd.StringVar(&cli.Format, "format", "", "Output format: table or json")
+		doctorCmd.IntVar(&cli.Limits.Untested, "max-untested", -1, "Fail when more source files have no test")
+		doctorCmd.IntVar(&cli.Limits.Orphaned, "max-orphaned", -1, "Fail when more test files have no source")
+		doctorCmd.IntVar(&cli.Limits.Broken, "max-broken", -1, "Fail when more files don't toggle back")
+		doctorCmd.IntVar(&cli.Limits.Ambiguous, "max-ambiguous", -1, "Fail when more files have several alternates")
+		doctorCmd.Parse(os.Args[2:])
+	case "create":
+		createCmd := cli.newFlagSet("create")
+		createCmd.StringVar(&cli.Editor, "editor", "", "Editor used to open the file")
+		createCmd.Parse(os.Args[2:])
+	case "run":
+		runCmd := cli.newFlagSet("run")
+		runCmd.IntVar(&cli.Line, "line", 0, "Run only the example or test at this line")
+		runCmd.Parse(os.Args[2:])
 	case "help", "-h", "--help":
 		printUsage()
 		os.Exit(0)
@@ -268,6 +643,7 @@ func NewCLI() *CLI {
 		printUsage()
 		os.Exit(1)
 	}
+	cli.Command = os.Args[1]
 
 	// Set defaults
 	if cli.Root == "" {
@@ -277,24 +653,243 @@ func NewCLI() *CLI {
 	return cli
 }
 
+// newFlagSet defines a subcommand with the flags shared by all commands
+func (c *CLI) newFlagSet(name string) *flag.FlagSet {
+	cmd := flag.NewFlagSet(name, flag.ExitOnError)
+	cmd.StringVar(&c.Root, "r", "", "Project root directory")
+	cmd.StringVar(&c.Root, "root", "", "Project root directory")
+	cmd.StringVar(&c.Path, "p", "", "Path to file")
+	cmd.StringVar(&c.Path, "path", "", "Path to file")
+	return cmd
+}
+
 // Run executes the CLI logic
 func (c *CLI) Run() error {
-	if c.Path == "" {
+	if c.Path == "" && c.Command != "doctor" {
 		return fmt.Errorf("path is required")
 	}
 
-	project := NewProject(c.Root)
-	sourceFile := NewSourceFile(c.Path, project)
+	if c.Command == "doctor" {
+		project := NewProject(c.Root)
+		if project.ConfigErr != nil {
+			return project.ConfigErr
+		}
+		return c.doctor(project)
+	}
+
+	root, path, err := ResolveFile(c.Root, c.Path)
+	if err != nil {
+		return err
+	}
+	project := NewProject(root)
+	if project.ConfigErr != nil {
+		return project.ConfigErr
+	}
+	sourceFile := NewSourceFile(path, project)
+
+	switch c.Command {
+	case "create":
+		testFile, err := sourceFile.CreateTestFile()
+		if err != nil {
+			return err
+		}
+		return c.open(project, testFile, 0)
+	case "run":
+		target, err := sourceFile.TestTarget(c.Line)
+		if err != nil {
+			return err
+		}
+		return project.RunTests(target)
+	case "cycle":
+		return c.cycle(project, sourceFile)
+	case "factory":
+		return c.factory(project, sourceFile)
+	case "sync":
+		return c.sync(project, sourceFile)
+	case "explain":
+		sourceFile.Explain(c.stdout())
+		return nil
+	default:
+		return c.lookup(project, sourceFile)
+	}
+}
+
+// lookup opens or prints the alternate file
+func (c *CLI) lookup(project *Project, sourceFile *SourceFile) error {
+	if c.Format != "" && c.Format != "json" {
+		return fmt.Errorf("unknown format: %s", c.Format)
+	}
+
+	if c.Verbose {
+		sourceFile.Explain(c.stderr())
+	}
+
+	result := sourceFile.Lookup()
+	// Fuzzy matches may test another file of the same name, only an
+	// existing candidate or constant alternate saves creating the test
+	if len(result.Alternates) == 0 && c.Create && !sourceFile.IsTestFile() {
+		testFile, err := sourceFile.CreateTestFile()
+		if err != nil {
+			return err
+		}
+		result.Alternate = testFile
+	}
+
+	if result.Alternate != "" && c.Line > 0 {
+		if rel, err := filepath.Rel(project.Root, result.Alternate); err == nil {
+			result.Line = sourceFile.AlternateLine(filepath.ToSlash(rel), c.Line)
+		}
+	}
+
+	if c.Format == "json" {
+		encoder := json.NewEncoder(c.stdout())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	if result.Alternate == "" {
+		// Let the user pick among the fuzzy matches, or exit silently
+		// when there are none. Scripts reading --print get paths only.
+		if c.Print {
+			return nil
+		}
+		for _, match := range result.Matches {
+			fmt.Fprintf(c.stdout(), "%.2f  %s\n", match.Score, match.Path)
+		}
+		return nil
+	}
+	if c.Print {
+		if result.Line > 0 {
+			fmt.Fprintf(c.stdout(), "%s:%d\n", result.Alternate, result.Line)
+			return nil
+		}
+		fmt.Fprintln(c.stdout(), result.Alternate)
+		return nil
+	}
+
+	return c.open(project, result.Alternate, result.Line)
+}
+
+// cycle opens or prints the next file related to this one, remembering
+// the ring of related files in the cache directory
+func (c *CLI) cycle(project *Project, sourceFile *SourceFile) error {
+	cacheDir := c.CacheDir
+	if cacheDir == "" {
+		var err error
+		if cacheDir, err = os.UserCacheDir(); err != nil {
+			return err
+		}
+	}
+	statePath := cycleStatePath(cacheDir, project.Root)
+
+	next, state := sourceFile.NextRelated(LoadCycleState(statePath))
+	if next == "" {
+		// No related files, exit silently
+		return nil
+	}
+	if err := state.Save(statePath); err != nil {
+		return err
+	}
+
+	// A view's controller opens at the view's action
+	line := sourceFile.ActionLine(next)
+	next = filepath.Join(project.Root, next)
+	if c.Print {
+		fmt.Fprintln(c.stdout(), next)
+		return nil
+	}
+	return c.open(project, next, line)
+}
+
+// factory opens or prints the factory file of a model, or the model of a
+// factory file
+func (c *CLI) factory(project *Project, sourceFile *SourceFile) error {
+	target := sourceFile.FactoryFile()
+	if sourceFile.IsFactory() {
+		target = sourceFile.AlternateFile()
+	}
+	if target == "" {
+		// No factory found, exit silently
+		return nil
+	}
+
+	if c.Print {
+		fmt.Fprintln(c.stdout(), target)
+		return nil
+	}
+	return c.open(project, target, 0)
+}
 
-	alternateFile := sourceFile.AlternateFile()
-	if alternateFile == "" {
-		// No alternate file found, exit silently
+// sync adds skeletons for the untested public methods to the test, then
+// opens or prints it at the first one added
+func (c *CLI) sync(project *Project, sourceFile *SourceFile) error {
+	result, err := sourceFile.SyncTests()
+	if err != nil {
+		return err
+	}
+
+	target := filepath.Join(project.Root, result.File)
+	if c.Print {
+		if result.Line > 0 {
+			fmt.Fprintf(c.stdout(), "%s:%d\n", target, result.Line)
+			return nil
+		}
+		fmt.Fprintln(c.stdout(), target)
 		return nil
 	}
+	return c.open(project, target, result.Line)
+}
+
+// doctor audits the whole project and fails when thresholds are exceeded
+func (c *CLI) doctor(project *Project) error {
+	report, err := project.Doctor()
+	if err != nil {
+		return err
+	}
+
+	switch c.Format {
+	case "", "table":
+		report.WriteTable(c.stdout())
+	case "json":
+		encoder := json.NewEncoder(c.stdout())
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown format: %s", c.Format)
+	}
 
-	// Execute zed command
-	cmd := exec.Command("zed", alternateFile)
-	return cmd.Run()
+	if exceeded := report.Exceeds(c.Limits); len(exceeded) > 0 {
+		return fmt.Errorf("thresholds exceeded: %s", strings.Join(exceeded, ", "))
+	}
+	return nil
+}
+
+// stdout returns the writer for printed output
+func (c *CLI) stdout() io.Writer {
+	if c.Stdout == nil {
+		return os.Stdout
+	}
+	return c.Stdout
+}
+
+// stderr returns the writer for diagnostics
+func (c *CLI) stderr() io.Writer {
+	if c.Stderr == nil {
+		return os.Stderr
+	}
+	return c.Stderr
+}
+
+// open opens the file in the editor selected by flag, config or
+// environment, at the line when it is positive
+func (c *CLI) open(project *Project, path string, line int) error {
+	opener, err := SelectOpener(c.Editor, project.Config)
+	if err != nil {
+		return err
+	}
+	return opener.Open(path, line)
 }
 
 // printVersion prints version information
@@ -310,21 +905,50 @@ func printUsage() {
 	fmt.Fprintln(os.Stderr, "")
 	fmt.Fprintln(os.Stderr, "Usage:")
 	fmt.Fprintln(os.Stderr, "  go-zed-test-toggle lookup [options]  Find and open the alternate file")
+	fmt.Fprintln(os.Stderr, "  go-zed-test-toggle create [options]  Create and open the missing test file")
+	fmt.Fprintln(os.Stderr, "  go-zed-test-toggle cycle [options]   Open the next related file on each invocation")
+	fmt.Fprintln(os.Stderr, "  go-zed-test-toggle factory [options] Open the FactoryBot factories of a model, or the model of a factory")
+	fmt.Fprintln(os.Stderr, "  go-zed-test-toggle sync [options]    Add pending tests for the untested public methods")
+	fmt.Fprintln(os.Stderr, "  go-zed-test-toggle run [options]     Run the tests for the file")
+	fmt.Fprintln(os.Stderr, "  go-zed-test-toggle explain [options] Explain how the alternate file is resolved")
+	fmt.Fprintln(os.Stderr, "  go-zed-test-toggle doctor [options]  Audit the source/test mapping of the whole project")
 	fmt.Fprintln(os.Stderr, "  go-zed-test-toggle version           Show version information")
 	fmt.Fprintln(os.Stderr, "  go-zed-test-toggle help              Show this help message")
 	fmt.Fprintln(os.Stderr, "")
-	fmt.Fprintln(os.Stderr, "Lookup options:")
-	fmt.Fprintln(os.Stderr, "  -p, --path string    Path to file (required)")
-	fmt.Fprintln(os.Stderr, "  -r, --root string    Project root directory (default: current directory)")
+	fmt.Fprintln(os.Stderr, "Options:")
+	fmt.Fprintln(os.Stderr, "  -p, --path string    Path to file, absolute or relative to the root (required, except for doctor)")
+	fmt.Fprintln(os.Stderr, "  -r, --root string    Project root directory (default: current directory); the nearest")
+	fmt.Fprintln(os.Stderr, "                       directory between the file and the root with a Gemfile, gemspec,")
+	fmt.Fprintln(os.Stderr, "                       .rspec or config/application.rb is used instead when there is one")
+	fmt.Fprintln(os.Stderr, "  --create             Create the test file when none exists (lookup)")
+	fmt.Fprintln(os.Stderr, "  --editor string      Editor: zed, nvim, vim, helix, vscode, sublime, rubymine or a")
+	fmt.Fprintln(os.Stderr, "                       command template like \"myedit {file}:{line}\"")
+	fmt.Fprintln(os.Stderr, "                       (lookup, create, cycle, factory, sync)")
+	fmt.Fprintln(os.Stderr, "  --print              Print the alternate path instead of opening it (lookup, cycle, factory, sync)")
+	fmt.Fprintln(os.Stderr, "  --format json        Print source, alternate and candidates as JSON (lookup)")
+	fmt.Fprintln(os.Stderr, "  --format table|json  Report format (doctor)")
+	fmt.Fprintln(os.Stderr, "  --verbose            Trace the resolution process on stderr (lookup)")
+	fmt.Fprintln(os.Stderr, "  --line int           Run only the example or test at this line (run), or open the")
+	fmt.Fprintln(os.Stderr, "                       alternate at the matching method or describe block (lookup)")
+	fmt.Fprintln(os.Stderr, "  --max-untested int   Fail when more source files have no test (doctor)")
+	fmt.Fprintln(os.Stderr, "  --max-orphaned int   Fail when more test files have no source (doctor)")
+	fmt.Fprintln(os.Stderr, "  --max-broken int     Fail when more files don't toggle back (doctor)")
+	fmt.Fprintln(os.Stderr, "  --max-ambiguous int  Fail when more files have several alternates (doctor)")
 	fmt.Fprintln(os.Stderr, "")
 	fmt.Fprintln(os.Stderr, "Examples:")
 	fmt.Fprintln(os.Stderr, `  go-zed-test-toggle lookup -p "lib/user.rb" -r "/path/to/project"`)
 	fmt.Fprintln(os.Stderr, `  go-zed-test-toggle lookup --path="$ZED_RELATIVE_FILE" --root="$ZED_WORKTREE_ROOT"`)
+	fmt.Fprintln(os.Stderr, `  go-zed-test-toggle doctor -r "/path/to/project" --max-untested 0`)
 }
 
 func main() {
 	cli := NewCLI()
 	if err := cli.Run(); err != nil {
+		// Propagate the exit code of a failing test run
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.ExitCode())
+		}
 		log.Fatal(err)
 	}
 }
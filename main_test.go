@@ -1,6 +1,8 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
@@ -77,6 +79,31 @@ func TestProject_IsSpec(t *testing.T) {
 			},
 			expected: true,
 		},
+		{
+			name:     "with spec/rails_helper.rb",
+			setup:    touch("spec/rails_helper.rb"),
+			expected: true,
+		},
+		{
+			name:     "with nested spec helper",
+			setup:    touch("engines/billing/spec/spec_helper.rb"),
+			expected: true,
+		},
+		{
+			name:     "nested spec helper below the depth limit",
+			setup:    touch("a/b/c/d/spec/spec_helper.rb"),
+			expected: false,
+		},
+		{
+			name:     "nested spec helper in a skipped directory",
+			setup:    touch("node_modules/pkg/spec/spec_helper.rb"),
+			expected: false,
+		},
+		{
+			name:     "nested spec helper in a minitest project",
+			setup:    touch("test/test_helper.rb", "engines/billing/spec/spec_helper.rb"),
+			expected: false,
+		},
 		{
 			name:     "without rspec indicators",
 			setup:    func(dir string) error { return nil },
@@ -99,6 +126,39 @@ func TestProject_IsSpec(t *testing.T) {
 	}
 }
 
+// touch returns a setup function creating empty files below the directory
+func touch(files ...string) func(dir string) error {
+	return func(dir string) error {
+		for _, file := range files {
+			path := filepath.Join(dir, filepath.FromSlash(file))
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(path, nil, 0644); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func TestProject_IsSpecCached(t *testing.T) {
+	dir := t.TempDir()
+	helper := filepath.Join(dir, "spec", "spec_helper.rb")
+	writeFile(t, helper, "")
+
+	project := NewProject(dir)
+	if !project.IsSpec() {
+		t.Fatalf("IsSpec() = false, want true")
+	}
+	if err := os.Remove(helper); err != nil {
+		t.Fatalf("remove failed: %v", err)
+	}
+	if !project.IsSpec() {
+		t.Errorf("IsSpec() = false after the first call, want the cached result")
+	}
+}
+
 func TestProject_SrcPaths(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -146,6 +206,7 @@ func TestProject_TestAnchor(t *testing.T) {
 	tests := []struct {
 		name     string
 		isSpec   bool
+		dotRSpec string
 		expected string
 	}{
 		{
@@ -158,6 +219,18 @@ func TestProject_TestAnchor(t *testing.T) {
 			isSpec:   false,
 			expected: "test",
 		},
+		{
+			name:     "rspec default path",
+			isSpec:   true,
+			dotRSpec: "--require spec_helper\n--default-path specs/unit\n",
+			expected: "specs/unit",
+		},
+		{
+			name:     "rspec default path with equals",
+			isSpec:   true,
+			dotRSpec: "--default-path=behaviours",
+			expected: "behaviours",
+		},
 	}
 
 	for _, tt := range tests {
@@ -169,6 +242,9 @@ func TestProject_TestAnchor(t *testing.T) {
 				f, _ := os.Create(filepath.Join(specDir, "spec_helper.rb"))
 				f.Close()
 			}
+			if tt.dotRSpec != "" {
+				os.WriteFile(filepath.Join(dir, ".rspec"), []byte(tt.dotRSpec), 0644)
+			}
 
 			project := NewProject(dir)
 			if got := project.TestAnchor(); got != tt.expected {
@@ -197,6 +273,30 @@ func TestProject_Testify(t *testing.T) {
 			input:    "user.rb",
 			expected: "user_test.rb",
 		},
+		{
+			name:     "rb directory",
+			isSpec:   true,
+			input:    "spec/rb/rbac.rb",
+			expected: "spec/rb/rbac_spec.rb",
+		},
+		{
+			name:     "only the extension is replaced",
+			isSpec:   true,
+			input:    "spec/lib/applications.rb",
+			expected: "spec/lib/applications_spec.rb",
+		},
+		{
+			name:     "not a ruby file",
+			isSpec:   true,
+			input:    "spec/assets/app.rb.js",
+			expected: "spec/assets/app.rb.js",
+		},
+		{
+			name:     "view template",
+			isSpec:   true,
+			input:    "spec/views/users/show.html.erb",
+			expected: "spec/views/users/show.html.erb_spec.rb",
+		},
 	}
 
 	for _, tt := range tests {
@@ -242,6 +342,24 @@ func TestSourceFile_IsTestFile(t *testing.T) {
 			isSpec:   false,
 			expected: true,
 		},
+		{
+			name:     "test-unit file with tc_ prefix",
+			filename: "test/tc_user.rb",
+			isSpec:   false,
+			expected: true,
+		},
+		{
+			name:     "prefix must start the base name",
+			filename: "app/models/contest_user.rb",
+			isSpec:   false,
+			expected: false,
+		},
+		{
+			name:     "test helper is not a test",
+			filename: "test/test_helper.rb",
+			isSpec:   false,
+			expected: false,
+		},
 		{
 			name:     "source file in rspec project",
 			filename: "user.rb",
@@ -321,6 +439,7 @@ func TestSourceFile_IsRequestSpec(t *testing.T) {
 	tests := []struct {
 		name     string
 		filename string
+		rspec    string
 		expected bool
 	}{
 		{
@@ -331,7 +450,7 @@ func TestSourceFile_IsRequestSpec(t *testing.T) {
 		{
 			name:     "regular request spec",
 			filename: "spec/requests/api/v1/foos_spec.rb",
-			expected: false,
+			expected: true,
 		},
 		{
 			name:     "controller spec in controllers dir",
@@ -343,11 +462,24 @@ func TestSourceFile_IsRequestSpec(t *testing.T) {
 			filename: "spec/models/user_spec.rb",
 			expected: false,
 		},
+		{
+			name:     "request spec in the default path",
+			filename: "specs/requests/users_spec.rb",
+			rspec:    "--default-path specs\n",
+			expected: true,
+		},
+		{
+			name:     "spec directory moved away by the default path",
+			filename: "spec/requests/users_spec.rb",
+			rspec:    "--default-path specs\n",
+			expected: false,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			dir := t.TempDir()
+			writeFile(t, filepath.Join(dir, ".rspec"), tt.rspec)
 			project := NewProject(dir)
 			sourceFile := NewSourceFile(tt.filename, project)
 
@@ -586,3 +718,91 @@ func TestFileExists(t *testing.T) {
 		})
 	}
 }
+
+func TestCLI_RunLookupOutput(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".rspec"), "")
+	writeFile(t, filepath.Join(dir, "lib", "user.rb"), "")
+	writeFile(t, filepath.Join(dir, "spec", "lib", "user_spec.rb"), "")
+
+	t.Run("print", func(t *testing.T) {
+		var out bytes.Buffer
+		cli := &CLI{Command: "lookup", Root: dir, Path: "lib/user.rb", Print: true, Stdout: &out}
+		if err := cli.Run(); err != nil {
+			t.Fatalf("Run() unexpected error: %v", err)
+		}
+		if got, want := out.String(), filepath.Join(dir, "spec/lib/user_spec.rb")+"\n"; got != want {
+			t.Errorf("output = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("print without alternate", func(t *testing.T) {
+		var out bytes.Buffer
+		cli := &CLI{Command: "lookup", Root: dir, Path: "lib/post.rb", Print: true, Stdout: &out}
+		if err := cli.Run(); err != nil {
+			t.Fatalf("Run() unexpected error: %v", err)
+		}
+		if out.Len() != 0 {
+			t.Errorf("output = %q, want nothing", out.String())
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var out bytes.Buffer
+		cli := &CLI{Command: "lookup", Root: dir, Path: "spec/lib/user_spec.rb", Format: "json", Stdout: &out}
+		if err := cli.Run(); err != nil {
+			t.Fatalf("Run() unexpected error: %v", err)
+		}
+
+		var result LookupResult
+		if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+			t.Fatalf("invalid json %q: %v", out.String(), err)
+		}
+		if result.Alternate != filepath.Join(dir, "lib/user.rb") {
+			t.Errorf("alternate = %q", result.Alternate)
+		}
+		if result.Framework != RSpec || result.ProjectType != KindRuby {
+			t.Errorf("framework = %q, project_type = %q", result.Framework, result.ProjectType)
+		}
+		if len(result.Candidates) == 0 {
+			t.Errorf("candidates is empty")
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		cli := &CLI{Command: "lookup", Root: dir, Path: "lib/user.rb", Format: "xml", Stdout: &bytes.Buffer{}}
+		if err := cli.Run(); err == nil {
+			t.Errorf("Run() expected error for unknown format")
+		}
+	})
+}
+
+func TestCLI_RunDoctor(t *testing.T) {
+	dir := setupDoctorProject(t)
+	noLimits := Thresholds{Untested: -1, Orphaned: -1, Broken: -1, Ambiguous: -1}
+
+	t.Run("json report", func(t *testing.T) {
+		var out bytes.Buffer
+		cli := &CLI{Command: "doctor", Root: dir, Format: "json", Limits: noLimits, Stdout: &out}
+		if err := cli.Run(); err != nil {
+			t.Fatalf("Run() unexpected error: %v", err)
+		}
+
+		var report Report
+		if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+			t.Fatalf("invalid json %q: %v", out.String(), err)
+		}
+		if !equalStrings(report.Untested, []string{"app/models/post.rb"}) {
+			t.Errorf("untested = %v", report.Untested)
+		}
+	})
+
+	t.Run("thresholds exceeded", func(t *testing.T) {
+		limits := noLimits
+		limits.Untested = 0
+		cli := &CLI{Command: "doctor", Root: dir, Limits: limits, Stdout: &bytes.Buffer{}}
+		if err := cli.Run(); err == nil {
+			t.Errorf("Run() expected error when thresholds are exceeded")
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// AlternateLine returns the line to open the alternate at for the cursor
+// line in this file, or 0 for the top of the file. From inside a method it
+// is the line of the method's describe '#method' (or '.method') group in
+// the spec, or of the first Minitest test named after it. From inside such
+// a group or test it is the line of the method's def. The alternate is
+// relative to the project root.
+func (s *SourceFile) AlternateLine(alternate string, line int) int {
+	if line <= 0 {
+		return 0
+	}
+	blocks, err := ScanRubyFile(filepath.Join(s.Project.Root, filepath.FromSlash(s.Filename)))
+	if err != nil {
+		return 0
+	}
+	alternateBlocks, err := ScanRubyFile(filepath.Join(s.Project.Root, filepath.FromSlash(alternate)))
+	if err != nil {
+		return 0
+	}
+
+	if s.IsTestFile() {
+		if s.Project.FrameworkFor(s.Filename) == RSpec {
+			return describedMethodLine(blocks, alternateBlocks, line)
+		}
+		return testedMethodLine(blocks, alternateBlocks, line)
+	}
+
+	method, ok := EnclosingBlock(blocks, line, BlockDef)
+	if !ok {
+		return 0
+	}
+	if test, ok := MethodTest(alternateBlocks, method, s.Project.FrameworkFor(alternate) == RSpec); ok {
+		return test.Line
+	}
+	return 0
+}
+
+// describedMethodLine returns the line of the def described by the
+// innermost '#method' or '.method' group containing the spec line
+func describedMethodLine(specBlocks, srcBlocks []RubyBlock, line int) int {
+	var describe RubyBlock
+	for _, block := range specBlocks {
+		if block.Kind == BlockDescribe && block.Contains(line) &&
+			(strings.HasPrefix(block.Name, "#") || strings.HasPrefix(block.Name, ".")) {
+			describe = block
+		}
+	}
+	if describe.Name == "" {
+		return 0
+	}
+	for _, block := range srcBlocks {
+		if block.Kind == BlockDef && block.DescribeName() == describe.Name {
+			return block.Line
+		}
+	}
+	return 0
+}
+
+// testedMethodLine returns the line of the def a Minitest test containing
+// the line is named after, preferring the longest matching method name
+func testedMethodLine(testBlocks, srcBlocks []RubyBlock, line int) int {
+	test, ok := EnclosingTest(testBlocks, line)
+	if !ok {
+		return 0
+	}
+	found, longest := 0, 0
+	for _, block := range srcBlocks {
+		if block.Kind != BlockDef || block.IsTest() {
+			continue
+		}
+		name := block.TestedName()
+		if len(name) > longest && strings.Contains(strings.TrimPrefix(test.Name, "test_"), name) {
+			found, longest = block.Line, len(name)
+		}
+	}
+	return found
+}
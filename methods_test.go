@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+const invoiceSource = `class Invoice
+  def self.build
+    new
+  end
+
+  def charge!
+    true
+  end
+
+  def total
+    0
+  end
+end
+`
+
+// setupMethodsProjects creates an RSpec app and a Minitest app with the
+// same model
+func setupMethodsProjects(t *testing.T) (string, string) {
+	t.Helper()
+	rspecDir := t.TempDir()
+	writeFile(t, filepath.Join(rspecDir, ".rspec"), "")
+	writeFile(t, filepath.Join(rspecDir, "app", "models", "invoice.rb"), invoiceSource)
+	writeFile(t, filepath.Join(rspecDir, "spec", "models", "invoice_spec.rb"), `RSpec.describe Invoice do
+  describe ".build" do
+    it "builds" do
+    end
+  end
+
+  describe "#charge!" do
+    context "when paid" do
+      it "charges" do
+      end
+    end
+  end
+end
+`)
+
+	minitestDir := t.TempDir()
+	writeFile(t, filepath.Join(minitestDir, "app", "models", "invoice.rb"), invoiceSource)
+	writeFile(t, filepath.Join(minitestDir, "test", "models", "invoice_test.rb"), `require "test_helper"
+
+class InvoiceTest < ActiveSupport::TestCase
+  test "charge! succeeds" do
+    assert true
+  end
+
+  def test_build
+    assert true
+  end
+end
+`)
+	return rspecDir, minitestDir
+}
+
+func TestSourceFile_AlternateLine(t *testing.T) {
+	rspecDir, minitestDir := setupMethodsProjects(t)
+
+	tests := []struct {
+		name      string
+		dir       string
+		file      string
+		alternate string
+		line      int
+		expected  int
+	}{
+		{"instance method to describe", rspecDir, "app/models/invoice.rb", "spec/models/invoice_spec.rb", 7, 7},
+		{"class method to describe", rspecDir, "app/models/invoice.rb", "spec/models/invoice_spec.rb", 3, 2},
+		{"method without describe", rspecDir, "app/models/invoice.rb", "spec/models/invoice_spec.rb", 11, 0},
+		{"outside any method", rspecDir, "app/models/invoice.rb", "spec/models/invoice_spec.rb", 1, 0},
+		{"nested example to def", rspecDir, "spec/models/invoice_spec.rb", "app/models/invoice.rb", 9, 6},
+		{"class describe to def", rspecDir, "spec/models/invoice_spec.rb", "app/models/invoice.rb", 3, 2},
+		{"top-level describe", rspecDir, "spec/models/invoice_spec.rb", "app/models/invoice.rb", 1, 0},
+		{"method to minitest test", minitestDir, "app/models/invoice.rb", "test/models/invoice_test.rb", 6, 4},
+		{"minitest test block to def", minitestDir, "test/models/invoice_test.rb", "app/models/invoice.rb", 5, 6},
+		{"minitest test method to def", minitestDir, "test/models/invoice_test.rb", "app/models/invoice.rb", 9, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sourceFile := NewSourceFile(tt.file, NewProject(tt.dir))
+			if got := sourceFile.AlternateLine(tt.alternate, tt.line); got != tt.expected {
+				t.Errorf("AlternateLine(%q, %d) = %d, want %d", tt.alternate, tt.line, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCLI_RunLookupLine(t *testing.T) {
+	rspecDir, _ := setupMethodsProjects(t)
+
+	var out bytes.Buffer
+	cli := &CLI{Command: "lookup", Root: rspecDir, Path: "app/models/invoice.rb", Line: 7, Print: true, Stdout: &out}
+	if err := cli.Run(); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if got, want := out.String(), filepath.Join(rspecDir, "spec/models/invoice_spec.rb")+":7\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	out.Reset()
+	cli = &CLI{Command: "lookup", Root: rspecDir, Path: "spec/models/invoice_spec.rb", Line: 9, Print: true, Stdout: &out}
+	if err := cli.Run(); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if got, want := out.String(), filepath.Join(rspecDir, "app/models/invoice.rb")+":6\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Opener opens a file in an editor, at the given line when it is positive
+type Opener interface {
+	Open(path string, line int) error
+}
+
+// CommandOpener opens files by running a command. The {file} and {line}
+// placeholders are replaced in each argument.
+type CommandOpener struct {
+	// Exec is the executable and any leading arguments
+	Exec []string
+	// Args are appended to Exec to open a file
+	Args []string
+	// LineArgs are appended to Exec to open a file at a line. When empty,
+	// Args is used and the line is ignored.
+	LineArgs []string
+}
+
+// builtinOpeners maps editor names to how they open a file at a line
+var builtinOpeners = map[string]CommandOpener{
+	"zed":      {Exec: []string{"zed"}, Args: []string{"{file}"}, LineArgs: []string{"{file}:{line}"}},
+	"nvim":     {Exec: []string{"nvim"}, Args: []string{"{file}"}, LineArgs: []string{"+{line}", "{file}"}},
+	"vim":      {Exec: []string{"vim"}, Args: []string{"{file}"}, LineArgs: []string{"+{line}", "{file}"}},
+	"hx":       {Exec: []string{"hx"}, Args: []string{"{file}"}, LineArgs: []string{"{file}:{line}"}},
+	"code":     {Exec: []string{"code"}, Args: []string{"{file}"}, LineArgs: []string{"--goto", "{file}:{line}"}},
+	"subl":     {Exec: []string{"subl"}, Args: []string{"{file}"}, LineArgs: []string{"{file}:{line}"}},
+	"rubymine": {Exec: []string{"rubymine"}, Args: []string{"{file}"}, LineArgs: []string{"--line", "{line}", "{file}"}},
+}
+
+// editorAliases maps alternative editor names to their builtin opener
+var editorAliases = map[string]string{
+	"neovim":  "nvim",
+	"helix":   "hx",
+	"vscode":  "code",
+	"sublime": "subl",
+}
+
+// NewOpener returns the opener for an editor specification, which is one
+// of:
+//   - a builtin editor name such as "zed", "nvim", "helix" or "vscode"
+//   - a command template using {file} and {line}, e.g. "myedit {file}:{line}"
+//   - a command line such as $EDITOR, e.g. "/usr/bin/nvim -f"
+//
+// An empty specification selects Zed.
+func NewOpener(spec string) (Opener, error) {
+	fields := strings.Fields(spec)
+	if len(fields) == 0 {
+		return builtinOpeners["zed"], nil
+	}
+
+	if strings.Contains(fields[0], "{") {
+		return nil, fmt.Errorf("editor %q must start with an executable", spec)
+	}
+	if strings.Contains(spec, "{file}") {
+		return CommandOpener{Exec: fields[:1], Args: fields[1:], LineArgs: fields[1:]}, nil
+	}
+
+	name := filepath.Base(fields[0])
+	if alias, ok := editorAliases[name]; ok {
+		return builtinOpeners[alias], nil
+	}
+	if builtin, ok := builtinOpeners[name]; ok {
+		// Keep the user's executable path and flags, e.g. "/opt/bin/nvim -f"
+		builtin.Exec = fields
+		return builtin, nil
+	}
+
+	// Unknown editors are only given the file
+	return CommandOpener{Exec: fields, Args: []string{"{file}"}}, nil
+}
+
+// SelectOpener picks the editor from the first non-empty of the flag, the
+// project configuration, $VISUAL and $EDITOR, defaulting to Zed. Within
+// Zed, which sets $ZED_TERM in its terminals, the environment is ignored
+// so that files open in Zed rather than in the shell's editor.
+func SelectOpener(flagValue string, config *Config) (Opener, error) {
+	candidates := []string{flagValue}
+	if config != nil {
+		candidates = append(candidates, config.Editor)
+	}
+	if os.Getenv("ZED_TERM") == "" {
+		candidates = append(candidates, os.Getenv("VISUAL"), os.Getenv("EDITOR"))
+	}
+
+	for _, spec := range candidates {
+		if strings.TrimSpace(spec) != "" {
+			return NewOpener(spec)
+		}
+	}
+	return NewOpener("")
+}
+
+// Command returns the command line that opens the file
+func (o CommandOpener) Command(path string, line int) []string {
+	args := o.Args
+	if line > 0 && len(o.LineArgs) > 0 {
+		args = o.LineArgs
+	}
+	if line <= 0 {
+		line = 1
+	}
+
+	command := append([]string{}, o.Exec...)
+	for _, arg := range args {
+		arg = strings.ReplaceAll(arg, "{file}", path)
+		arg = strings.ReplaceAll(arg, "{line}", strconv.Itoa(line))
+		command = append(command, arg)
+	}
+	return command
+}
+
+// Open runs the command that opens the file. The command shares the
+// terminal, so that editors such as nvim or hx can run in it.
+func (o CommandOpener) Open(path string, line int) error {
+	command := o.Command(path, line)
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestNewOpener(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		line     int
+		expected []string
+	}{
+		{name: "default is zed", spec: "", line: 0, expected: []string{"zed", "/p/user.rb"}},
+		{name: "zed at line", spec: "zed", line: 12, expected: []string{"zed", "/p/user.rb:12"}},
+		{name: "neovim", spec: "nvim", line: 12, expected: []string{"nvim", "+12", "/p/user.rb"}},
+		{name: "vim without line", spec: "vim", line: 0, expected: []string{"vim", "/p/user.rb"}},
+		{name: "helix alias", spec: "helix", line: 12, expected: []string{"hx", "/p/user.rb:12"}},
+		{name: "vscode alias", spec: "vscode", line: 12, expected: []string{"code", "--goto", "/p/user.rb:12"}},
+		{name: "sublime", spec: "subl", line: 12, expected: []string{"subl", "/p/user.rb:12"}},
+		{name: "rubymine", spec: "rubymine", line: 12, expected: []string{"rubymine", "--line", "12", "/p/user.rb"}},
+		{name: "editor path with flags", spec: "/opt/bin/nvim -f", line: 12, expected: []string{"/opt/bin/nvim", "-f", "+12", "/p/user.rb"}},
+		{name: "command template", spec: "myedit --open {file}:{line}", line: 12, expected: []string{"myedit", "--open", "/p/user.rb:12"}},
+		{name: "command template without line", spec: "myedit {file}:{line}", line: 0, expected: []string{"myedit", "/p/user.rb:1"}},
+		{name: "unknown editor", spec: "emacs -nw", line: 12, expected: []string{"emacs", "-nw", "/p/user.rb"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opener, err := NewOpener(tt.spec)
+			if err != nil {
+				t.Fatalf("NewOpener(%q) unexpected error: %v", tt.spec, err)
+			}
+			got := opener.(CommandOpener).Command("/p/user.rb", tt.line)
+			if !equalStrings(got, tt.expected) {
+				t.Errorf("Command() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := NewOpener("{file}"); err == nil {
+		t.Errorf("NewOpener(%q) expected error", "{file}")
+	}
+}
+
+func TestSelectOpener(t *testing.T) {
+	tests := []struct {
+		name     string
+		flag     string
+		config   *Config
+		visual   string
+		editor   string
+		zed      bool
+		expected string
+	}{
+		{name: "flag wins", flag: "nvim", config: &Config{Editor: "code"}, visual: "subl", editor: "vim", expected: "nvim"},
+		{name: "config before environment", config: &Config{Editor: "code"}, visual: "subl", editor: "vim", expected: "code"},
+		{name: "visual before editor", config: &Config{}, visual: "subl", editor: "vim", expected: "subl"},
+		{name: "editor", config: &Config{}, editor: "vim", expected: "vim"},
+		{name: "default", config: nil, expected: "zed"},
+		{name: "zed ignores the environment", config: &Config{}, visual: "subl", editor: "vim", zed: true, expected: "zed"},
+		{name: "zed with config", config: &Config{Editor: "code"}, editor: "vim", zed: true, expected: "code"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("VISUAL", tt.visual)
+			t.Setenv("EDITOR", tt.editor)
+			t.Setenv("ZED_TERM", "")
+			if tt.zed {
+				t.Setenv("ZED_TERM", "true")
+			}
+
+			opener, err := SelectOpener(tt.flag, tt.config)
+			if err != nil {
+				t.Fatalf("SelectOpener() unexpected error: %v", err)
+			}
+			if got := opener.(CommandOpener).Command("user.rb", 0)[0]; got != tt.expected {
+				t.Errorf("SelectOpener() executable = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCommandOpener_Open(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("editor scripts require a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	editor := filepath.Join(dir, "editor")
+	writeFile(t, editor, "#!/bin/sh\nread input\necho \"$1 $input\"\necho \"$1\" >&2\n")
+	if err := os.Chmod(editor, 0755); err != nil {
+		t.Fatalf("chmod failed: %v", err)
+	}
+
+	// The editor gets the terminal: our stdin, stdout and stderr
+	stdin := filepath.Join(dir, "stdin")
+	writeFile(t, stdin, "from terminal\n")
+	in, err := os.Open(stdin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	out, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	errOut, err := os.Create(filepath.Join(dir, "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer errOut.Close()
+
+	oldStdin, oldStdout, oldStderr := os.Stdin, os.Stdout, os.Stderr
+	os.Stdin, os.Stdout, os.Stderr = in, out, errOut
+	err = CommandOpener{Exec: []string{editor}, Args: []string{"{file}"}, LineArgs: []string{"{file}:{line}"}}.Open("user.rb", 12)
+	os.Stdin, os.Stdout, os.Stderr = oldStdin, oldStdout, oldStderr
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	if got, _ := os.ReadFile(filepath.Join(dir, "stdout")); string(got) != "user.rb:12 from terminal\n" {
+		t.Errorf("stdout = %q, want %q", got, "user.rb:12 from terminal\n")
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "stderr")); string(got) != "user.rb:12\n" {
+		t.Errorf("stderr = %q, want %q", got, "user.rb:12\n")
+	}
+}
+
+func TestLoadConfig_Editor(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ConfigFileName), `{"editor": "nvim"}`)
+
+	config, err := LoadConfig(dir)
+	if err != nil {
+		t.Fatalf("LoadConfig() unexpected error: %v", err)
+	}
+	if config.Editor != "nvim" {
+		t.Errorf("Editor = %q, want %q", config.Editor, "nvim")
+	}
+}
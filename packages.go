@@ -0,0 +1,82 @@
+package main
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// packageMarkers are glob patterns marking a directory as a package with
+// its own source and test roots: packwerk packs, Rails engines and
+// nested gems
+var packageMarkers = []string{
+	"package.yml",
+	"*.gemspec",
+	filepath.Join("lib", "*", "engine.rb"),
+}
+
+// isPackageDir reports whether a directory holds a package marker
+func isPackageDir(dir string) bool {
+	for _, marker := range packageMarkers {
+		if matches, _ := filepath.Glob(filepath.Join(dir, marker)); len(matches) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Package returns the project scoped to the innermost package that owns
+// the file, and the package directory relative to the project root. It
+// returns nil when the file belongs directly to the project. Packages are
+// detected once per directory.
+func (p *Project) Package(filename string) (*Project, string) {
+	for dir := path.Dir(filename); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if pkg := p.packageAt(dir); pkg != nil {
+			return pkg, dir
+		}
+	}
+	return nil, ""
+}
+
+// packageAt returns the package at the directory, relative to the project
+// root, or nil when the directory isn't a package
+func (p *Project) packageAt(dir string) *Project {
+	if pkg, ok := p.packages[dir]; ok {
+		return pkg
+	}
+	var pkg *Project
+	if isPackageDir(filepath.Join(p.Root, dir)) {
+		pkg = NewProject(filepath.Join(p.Root, dir))
+		pkg.Parent = p
+	}
+	if p.packages == nil {
+		p.packages = map[string]*Project{}
+	}
+	p.packages[dir] = pkg
+	return pkg
+}
+
+// scoped returns the file relative to the package that owns it, along
+// with the package directory, or the file itself and "" outside packages
+func (s *SourceFile) scoped() (*SourceFile, string) {
+	pkg, dir := s.Project.Package(s.Filename)
+	if pkg == nil {
+		return s, ""
+	}
+	return NewSourceFile(strings.TrimPrefix(s.Filename, dir+"/"), pkg), dir
+}
+
+// packageCandidates returns the alternates resolved within the package
+// that owns the file, relative to the project root
+func (s *SourceFile) packageCandidates() []string {
+	scoped, dir := s.scoped()
+	if dir == "" {
+		return nil
+	}
+
+	var candidates []string
+	for _, candidate := range scoped.Candidates() {
+		candidates = append(candidates, path.Join(dir, candidate))
+	}
+	return candidates
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestProject_Package(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "package.yml"), "")
+	writeFile(t, filepath.Join(dir, "packs", "billing", "package.yml"), "")
+	writeFile(t, filepath.Join(dir, "engines", "admin", "lib", "admin", "engine.rb"), "")
+
+	tests := []struct {
+		name     string
+		filename string
+		expected string
+	}{
+		{name: "pack file", filename: "packs/billing/app/models/invoice.rb", expected: "packs/billing"},
+		{name: "pack spec", filename: "packs/billing/spec/models/invoice_spec.rb", expected: "packs/billing"},
+		{name: "engine file", filename: "engines/admin/app/models/admin/user.rb", expected: "engines/admin"},
+		{name: "root package is the project itself", filename: "app/models/user.rb", expected: ""},
+	}
+
+	project := NewProject(dir)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg, got := project.Package(tt.filename)
+			if got != tt.expected {
+				t.Errorf("Package() dir = %q, want %q", got, tt.expected)
+			}
+			if tt.expected == "" {
+				if pkg != nil {
+					t.Errorf("Package() = %v, want nil", pkg)
+				}
+				return
+			}
+			if pkg.Root != filepath.Join(dir, tt.expected) || pkg.Parent != project {
+				t.Errorf("Package() root = %q, parent = %v", pkg.Root, pkg.Parent)
+			}
+		})
+	}
+}
+
+func TestProject_PackageCached(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "packs", "billing", "package.yml"), "")
+
+	project := NewProject(dir)
+	first, _ := project.Package("packs/billing/app/models/invoice.rb")
+	second, _ := project.Package("packs/billing/spec/models/invoice_spec.rb")
+	if first == nil || first != second {
+		t.Errorf("Package() = %p then %p, want the same package", first, second)
+	}
+}
+
+func TestSourceFile_AlternateFileInPackage(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".rspec"), "")
+	writeFile(t, filepath.Join(dir, "packs", "billing", "package.yml"), "")
+	writeFile(t, filepath.Join(dir, "packs", "billing", "app", "models", "invoice.rb"), "")
+	writeFile(t, filepath.Join(dir, "packs", "billing", "spec", "models", "invoice_spec.rb"), "")
+	writeFile(t, filepath.Join(dir, "packs", "billing", "lib", "billing", "parser.rb"), "")
+	writeFile(t, filepath.Join(dir, "packs", "billing", "spec", "lib", "billing", "parser_spec.rb"), "")
+
+	project := NewProject(dir)
+	tests := []struct {
+		filename string
+		expected string
+	}{
+		{filename: "packs/billing/app/models/invoice.rb", expected: "packs/billing/spec/models/invoice_spec.rb"},
+		{filename: "packs/billing/spec/models/invoice_spec.rb", expected: "packs/billing/app/models/invoice.rb"},
+		{filename: "packs/billing/lib/billing/parser.rb", expected: "packs/billing/spec/lib/billing/parser_spec.rb"},
+		{filename: "packs/billing/spec/lib/billing/parser_spec.rb", expected: "packs/billing/lib/billing/parser.rb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			got := NewSourceFile(tt.filename, project).AlternateFile()
+			if want := filepath.Join(dir, tt.expected); got != want {
+				t.Errorf("AlternateFile() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestSourceFile_PreferredTestPathInPackage(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "spec", "spec_helper.rb"), "")
+	writeFile(t, filepath.Join(dir, "packs", "billing", "package.yml"), "")
+
+	sourceFile := NewSourceFile("packs/billing/app/models/billing/invoice.rb", NewProject(dir))
+	if got, want := sourceFile.PreferredTestPath(), "packs/billing/spec/models/billing/invoice_spec.rb"; got != want {
+		t.Errorf("PreferredTestPath() = %q, want %q", got, want)
+	}
+	if got, want := sourceFile.ConstantName(), "Billing::Invoice"; got != want {
+		t.Errorf("ConstantName() = %q, want %q", got, want)
+	}
+}
+
+func TestProject_PackageFramework(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".rspec"), "")
+	writeFile(t, filepath.Join(dir, "packs", "billing", "package.yml"), "")
+	writeFile(t, filepath.Join(dir, "packs", "billing", "spec", "models", "invoice_spec.rb"), "")
+	writeFile(t, filepath.Join(dir, "engines", "legacy", "legacy.gemspec"), "")
+	writeFile(t, filepath.Join(dir, "engines", "legacy", "test", "test_helper.rb"), "")
+	writeFile(t, filepath.Join(dir, "engines", "plain", "plain.gemspec"), "")
+	writeFile(t, filepath.Join(dir, "engines", "plain", "test", "plain_test.rb"), "")
+
+	tests := []struct {
+		name     string
+		filename string
+		expected Framework
+	}{
+		{name: "pack inherits rspec", filename: "packs/billing/app/models/invoice.rb", expected: RSpec},
+		{name: "engine with test helper", filename: "engines/legacy/lib/legacy.rb", expected: Minitest},
+		{name: "engine with only a test directory", filename: "engines/plain/lib/plain.rb", expected: Minitest},
+	}
+
+	project := NewProject(dir)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg, _ := project.Package(tt.filename)
+			if pkg == nil {
+				t.Fatalf("Package() = nil")
+			}
+			if got := pkg.Framework(); got != tt.expected {
+				t.Errorf("Framework() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSourceFile_AlternateFileInNestedEngine(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".rspec"), "")
+	writeFile(t, filepath.Join(dir, "config", "application.rb"), "")
+
+	engine := filepath.Join(dir, "engines", "billing")
+	writeFile(t, filepath.Join(engine, "billing.gemspec"), "")
+	writeFile(t, filepath.Join(engine, "lib", "billing", "engine.rb"), "")
+	writeFile(t, filepath.Join(engine, "test", "test_helper.rb"), "")
+	writeFile(t, filepath.Join(engine, "app", "models", "billing", "invoice.rb"), "")
+	writeFile(t, filepath.Join(engine, "test", "models", "billing", "invoice_test.rb"), "")
+	writeFile(t, filepath.Join(engine, "lib", "billing", "parser.rb"), "")
+	writeFile(t, filepath.Join(engine, "test", "billing", "parser_test.rb"), "")
+
+	project := NewProject(dir)
+	tests := []struct {
+		filename string
+		expected string
+	}{
+		{filename: "engines/billing/app/models/billing/invoice.rb", expected: "engines/billing/test/models/billing/invoice_test.rb"},
+		{filename: "engines/billing/test/models/billing/invoice_test.rb", expected: "engines/billing/app/models/billing/invoice.rb"},
+		{filename: "engines/billing/lib/billing/parser.rb", expected: "engines/billing/test/billing/parser_test.rb"},
+		{filename: "engines/billing/test/billing/parser_test.rb", expected: "engines/billing/lib/billing/parser.rb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			sourceFile := NewSourceFile(tt.filename, project)
+			got := sourceFile.AlternateFile()
+			if want := filepath.Join(dir, tt.expected); got != want {
+				t.Errorf("AlternateFile() = %q, want %q", got, want)
+			}
+		})
+	}
+
+	if !NewSourceFile("engines/billing/test/billing/parser_test.rb", project).IsTestFile() {
+		t.Errorf("IsTestFile() = false for a Minitest test in a Minitest engine")
+	}
+}
+
+func TestProject_SrcPathsForEngine(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "billing.gemspec"), "")
+	writeFile(t, filepath.Join(dir, "lib", "billing", "engine.rb"), "")
+
+	if got := NewProject(dir).SrcPaths(); !equalStrings(got, []string{"app", "lib"}) {
+		t.Errorf("SrcPaths() = %v, want [app lib]", got)
+	}
+}
+
+func TestSourceFile_MinitestEngineInRSpecApp(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".rspec"), "")
+	writeFile(t, filepath.Join(dir, "config", "application.rb"), "")
+	writeFile(t, filepath.Join(dir, "bin", "rspec"), "")
+
+	engine := filepath.Join(dir, "engines", "foo")
+	writeFile(t, filepath.Join(engine, "foo.gemspec"), "")
+	writeFile(t, filepath.Join(engine, "Gemfile"), "")
+	writeFile(t, filepath.Join(engine, "lib", "foo", "engine.rb"), "")
+	writeFile(t, filepath.Join(engine, "test", "test_helper.rb"), "")
+	writeFile(t, filepath.Join(engine, "app", "models", "foo", "bar.rb"), `module Foo
+  class Bar
+    def total
+      1
+    end
+
+    def refund
+    end
+  end
+end
+`)
+	writeFile(t, filepath.Join(engine, "test", "models", "foo", "bar_test.rb"), `require "test_helper"
+
+module Foo
+  class BarTest < ActiveSupport::TestCase
+    def test_total
+      assert true
+    end
+  end
+end
+`)
+
+	project := NewProject(dir)
+	sourceFile := NewSourceFile("engines/foo/app/models/foo/bar.rb", project)
+	testFile := "engines/foo/test/models/foo/bar_test.rb"
+
+	target, err := sourceFile.TestTarget(3)
+	if err != nil {
+		t.Fatalf("TestTarget() error = %v", err)
+	}
+	if want := (TestTarget{File: testFile, Name: "/total/"}); target != want {
+		t.Errorf("TestTarget() = %+v, want %+v", target, want)
+	}
+
+	runner, scoped := project.runner(target)
+	if runner.Root != engine {
+		t.Errorf("runner root = %q, want %q", runner.Root, engine)
+	}
+	want := []string{"bundle", "exec", "ruby", "-Itest", "test/models/foo/bar_test.rb", "-n", "/total/"}
+	if got := runner.TestCommand(scoped); !equalStrings(got, want) {
+		t.Errorf("TestCommand() = %v, want %v", got, want)
+	}
+
+	if got := sourceFile.AlternateLine(testFile, 3); got != 5 {
+		t.Errorf("AlternateLine() = %d, want 5", got)
+	}
+
+	result, err := sourceFile.SyncTests()
+	if err != nil {
+		t.Fatalf("SyncTests() error = %v", err)
+	}
+	if !equalStrings(result.Added, []string{"test_refund"}) {
+		t.Errorf("SyncTests() added %v, want [test_refund]", result.Added)
+	}
+}
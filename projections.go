@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ProjectionsFileName is the name of the vim-projectionist configuration file
+const ProjectionsFileName = ".projections.json"
+
+// placeholderRegex matches projectionist placeholders such as {} or {dirname}
+var placeholderRegex = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// Projection is a single vim-projectionist pattern and its alternates
+type Projection struct {
+	Pattern    string
+	Alternates []string
+}
+
+// LoadProjections reads the projectionist file from the project root.
+// Only entries declaring an "alternate" are kept. They are ordered with
+// the most specific (longest) pattern first, as projectionist does.
+// A missing file is not an error and yields no projections.
+func LoadProjections(root string) ([]Projection, error) {
+	filename := filepath.Join(root, ProjectionsFileName)
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading %s: %w", filename, err)
+	}
+
+	var raw map[string]struct {
+		Alternate json.RawMessage `json:"alternate"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", filename, err)
+	}
+
+	var projections []Projection
+	for pattern, entry := range raw {
+		if len(entry.Alternate) == 0 || !strings.Contains(pattern, "*") {
+			continue
+		}
+		alternates, err := decodeAlternates(entry.Alternate)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: alternate for %q: %w", filename, pattern, err)
+		}
+		projections = append(projections, Projection{Pattern: pattern, Alternates: alternates})
+	}
+
+	sort.Slice(projections, func(i, j int) bool {
+		a, b := projections[i].Pattern, projections[j].Pattern
+		if len(a) != len(b) {
+			return len(a) > len(b)
+		}
+		return a < b
+	})
+	return projections, nil
+}
+
+// decodeAlternates accepts either a single alternate or a list of them
+func decodeAlternates(data json.RawMessage) ([]string, error) {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		return []string{single}, nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("expected a string or a list of strings")
+	}
+	return list, nil
+}
+
+// Match returns the part of filename matched by the pattern's wildcard.
+// Like projectionist, "*" (or "**/*") matches across directories.
+func (p Projection) Match(filename string) (string, bool) {
+	pattern := strings.Replace(p.Pattern, "**/*", "*", 1)
+	star := strings.Index(pattern, "*")
+	if star < 0 || strings.Contains(pattern[star+1:], "*") {
+		return "", false
+	}
+
+	prefix, suffix := pattern[:star], pattern[star+1:]
+	if len(filename) <= len(prefix)+len(suffix) {
+		return "", false
+	}
+	if !strings.HasPrefix(filename, prefix) || !strings.HasSuffix(filename, suffix) {
+		return "", false
+	}
+	return filename[len(prefix) : len(filename)-len(suffix)], true
+}
+
+// Expand returns the alternates for a matched path, with placeholders
+// replaced. Alternates using unsupported placeholders are skipped.
+func (p Projection) Expand(match string) []string {
+	var expanded []string
+	for _, alternate := range p.Alternates {
+		ok := true
+		result := placeholderRegex.ReplaceAllStringFunc(alternate, func(placeholder string) string {
+			value, supported := expandPlaceholder(placeholder[1:len(placeholder)-1], match)
+			if !supported {
+				ok = false
+			}
+			return value
+		})
+		if ok {
+			expanded = append(expanded, path.Clean(result))
+		}
+	}
+	return expanded
+}
+
+// expandPlaceholder applies a chain of projectionist transformations
+// such as "dirname" or "basename" to the matched text
+func expandPlaceholder(chain, match string) (string, bool) {
+	value := match
+	if chain == "" {
+		return value, true
+	}
+	for _, transform := range strings.Split(chain, "|") {
+		switch transform {
+		case "dirname":
+			if i := strings.LastIndex(value, "/"); i >= 0 {
+				value = value[:i]
+			} else {
+				value = ""
+			}
+		case "basename":
+			value = value[strings.LastIndex(value, "/")+1:]
+		default:
+			return "", false
+		}
+	}
+	return value, true
+}
+
+// projectedAlternates returns the alternates declared for the file in
+// the project's projectionist file, most specific pattern first
+func (s *SourceFile) projectedAlternates() []string {
+	var candidates []string
+	for _, projection := range s.Project.Projections {
+		if match, ok := projection.Match(s.Filename); ok {
+			candidates = append(candidates, projection.Expand(match)...)
+		}
+	}
+	return candidates
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadProjections(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ProjectionsFileName), `{
+		"app/*.rb": {"alternate": "spec/{}_spec.rb", "type": "source"},
+		"app/models/*.rb": {"alternate": ["spec/models/{}_spec.rb", "test/models/{}_test.rb"]},
+		"README.md": {"type": "doc"},
+		"*.gemspec": {"type": "gemspec"}
+	}`)
+
+	projections, err := LoadProjections(dir)
+	if err != nil {
+		t.Fatalf("LoadProjections() unexpected error: %v", err)
+	}
+	if len(projections) != 2 {
+		t.Fatalf("LoadProjections() returned %d projections, want 2", len(projections))
+	}
+	if projections[0].Pattern != "app/models/*.rb" {
+		t.Errorf("projections[0].Pattern = %q, want most specific pattern first", projections[0].Pattern)
+	}
+	if !equalStrings(projections[0].Alternates, []string{"spec/models/{}_spec.rb", "test/models/{}_test.rb"}) {
+		t.Errorf("projections[0].Alternates = %v", projections[0].Alternates)
+	}
+	if !equalStrings(projections[1].Alternates, []string{"spec/{}_spec.rb"}) {
+		t.Errorf("projections[1].Alternates = %v", projections[1].Alternates)
+	}
+}
+
+func TestLoadProjections_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "invalid json", content: `{"app/*.rb": `},
+		{name: "invalid alternate", content: `{"app/*.rb": {"alternate": 42}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, filepath.Join(dir, ProjectionsFileName), tt.content)
+			if _, err := LoadProjections(dir); err == nil {
+				t.Errorf("LoadProjections() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestProjection_MatchAndExpand(t *testing.T) {
+	tests := []struct {
+		name       string
+		projection Projection
+		filename   string
+		expected   []string
+	}{
+		{
+			name:       "simple placeholder",
+			projection: Projection{Pattern: "app/*.rb", Alternates: []string{"spec/{}_spec.rb"}},
+			filename:   "app/models/user.rb",
+			expected:   []string{"spec/models/user_spec.rb"},
+		},
+		{
+			name:       "double star pattern",
+			projection: Projection{Pattern: "lib/**/*.rb", Alternates: []string{"spec/lib/{}_spec.rb"}},
+			filename:   "lib/my_gem/user.rb",
+			expected:   []string{"spec/lib/my_gem/user_spec.rb"},
+		},
+		{
+			name:       "dirname and basename",
+			projection: Projection{Pattern: "spec/*_spec.rb", Alternates: []string{"app/{dirname}/{basename}.rb"}},
+			filename:   "spec/models/user_spec.rb",
+			expected:   []string{"app/models/user.rb"},
+		},
+		{
+			name:       "dirname without directory",
+			projection: Projection{Pattern: "lib/*.rb", Alternates: []string{"spec/{dirname}/{basename}_spec.rb"}},
+			filename:   "lib/user.rb",
+			expected:   []string{"spec/user_spec.rb"},
+		},
+		{
+			name:       "unsupported transformation is skipped",
+			projection: Projection{Pattern: "app/*.rb", Alternates: []string{"spec/{camelcase}_spec.rb", "spec/{}_spec.rb"}},
+			filename:   "app/user.rb",
+			expected:   []string{"spec/user_spec.rb"},
+		},
+		{
+			name:       "no match",
+			projection: Projection{Pattern: "app/*.rb", Alternates: []string{"spec/{}_spec.rb"}},
+			filename:   "lib/user.rb",
+			expected:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			if match, ok := tt.projection.Match(tt.filename); ok {
+				got = tt.projection.Expand(match)
+			}
+			if !equalStrings(got, tt.expected) {
+				t.Errorf("alternates for %q = %v, want %v", tt.filename, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSourceFile_AlternateFileWithProjections(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".rspec"), "")
+	writeFile(t, filepath.Join(dir, ProjectionsFileName), `{
+		"app/domain/*.rb": {"alternate": "spec/unit/{}_spec.rb"},
+		"spec/unit/*_spec.rb": {"alternate": "app/domain/{}.rb"}
+	}`)
+	writeFile(t, filepath.Join(dir, "app", "domain", "billing", "invoice.rb"), "")
+	writeFile(t, filepath.Join(dir, "spec", "unit", "billing", "invoice_spec.rb"), "")
+	// A conventional spec that the heuristics would otherwise pick
+	writeFile(t, filepath.Join(dir, "spec", "domain", "billing", "invoice_spec.rb"), "")
+
+	project := NewProject(dir)
+	if project.ConfigErr != nil {
+		t.Fatalf("unexpected config error: %v", project.ConfigErr)
+	}
+
+	src := NewSourceFile("app/domain/billing/invoice.rb", project)
+	if got, want := src.AlternateFile(), filepath.Join(dir, "spec/unit/billing/invoice_spec.rb"); got != want {
+		t.Errorf("AlternateFile() = %q, want %q", got, want)
+	}
+
+	test := NewSourceFile("spec/unit/billing/invoice_spec.rb", project)
+	if got, want := test.AlternateFile(), filepath.Join(dir, "app/domain/billing/invoice.rb"); got != want {
+		t.Errorf("AlternateFile() = %q, want %q", got, want)
+	}
+
+	// Falls back to the heuristics when no projection applies
+	other := NewSourceFile("spec/domain/billing/invoice_spec.rb", project)
+	if got, want := other.AlternateFile(), filepath.Join(dir, "app/domain/billing/invoice.rb"); got != want {
+		t.Errorf("AlternateFile() = %q, want %q", got, want)
+	}
+}
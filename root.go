@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// rootMarkers are glob patterns whose match marks a Ruby project root
+var rootMarkers = []string{
+	"Gemfile",
+	"*.gemspec",
+	".rspec",
+	filepath.Join("config", "application.rb"),
+	ConfigFileName,
+}
+
+// FindProjectRoot walks up from dir to the nearest directory holding a
+// Gemfile, gemspec, .rspec, config/application.rb or configuration file.
+// The search doesn't go above limit, unless limit is empty.
+func FindProjectRoot(dir, limit string) (string, bool) {
+	dir = filepath.Clean(dir)
+	for {
+		for _, marker := range rootMarkers {
+			if matches, _ := filepath.Glob(filepath.Join(dir, marker)); len(matches) > 0 {
+				return dir, true
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir || (limit != "" && dir == filepath.Clean(limit)) {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// ResolveFile returns the project root for a file and the file's path
+// relative to it. The path may be absolute or relative to root. The
+// nearest enclosing project root at or below root is used, falling back
+// to root; for a file outside root, the nearest one anywhere above it.
+// Within root, a package such as an engine or nested gem resolves to the
+// project enclosing it, see Project.Package.
+func ResolveFile(root, path string) (string, string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", "", err
+	}
+
+	absPath := path
+	if !filepath.IsAbs(path) {
+		absPath = filepath.Join(absRoot, path)
+	}
+	absPath = filepath.Clean(absPath)
+
+	limit := absRoot
+	if isOutside(absRoot, absPath) {
+		limit = ""
+	}
+	if found, ok := FindProjectRoot(filepath.Dir(absPath), limit); ok {
+		absRoot = found
+		// Engines and nested gems within root belong to the enclosing
+		// project, which scopes them as packages with itself as parent
+		for limit != "" && absRoot != limit && isPackageDir(absRoot) {
+			outer, ok := FindProjectRoot(filepath.Dir(absRoot), limit)
+			if !ok {
+				break
+			}
+			absRoot = outer
+		}
+	}
+
+	if isOutside(absRoot, absPath) {
+		return "", "", fmt.Errorf("%s is outside the project root %s", path, absRoot)
+	}
+	rel, err := filepath.Rel(absRoot, absPath)
+	if err != nil {
+		return "", "", err
+	}
+	return absRoot, filepath.ToSlash(rel), nil
+}
+
+// isOutside reports whether path is outside the directory root
+func isOutside(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
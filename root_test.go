@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindProjectRoot(t *testing.T) {
+	tests := []struct {
+		name     string
+		marker   string
+		start    string
+		expected string
+	}{
+		{name: "Gemfile", marker: "Gemfile", start: "app/models", expected: "."},
+		{name: "gemspec", marker: "my_gem.gemspec", start: "lib/my_gem", expected: "."},
+		{name: ".rspec", marker: ".rspec", start: "spec/models", expected: "."},
+		{name: "rails application", marker: "config/application.rb", start: "app/models", expected: "."},
+		{name: "config file", marker: ConfigFileName, start: "src", expected: "."},
+		{name: "nearest wins", marker: "engines/billing/billing.gemspec", start: "engines/billing/lib/billing", expected: "engines/billing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, filepath.Join(dir, "Gemfile.lock"), "")
+			writeFile(t, filepath.Join(dir, tt.marker), "")
+			writeFile(t, filepath.Join(dir, tt.start, "file.rb"), "")
+
+			got, ok := FindProjectRoot(filepath.Join(dir, tt.start), "")
+			if !ok {
+				t.Fatalf("FindProjectRoot() found no root")
+			}
+			if want := filepath.Join(dir, tt.expected); got != want {
+				t.Errorf("FindProjectRoot() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestFindProjectRoot_Limit(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "Gemfile"), "")
+	writeFile(t, filepath.Join(dir, "ws", "test", "models", "a_test.rb"), "")
+
+	if got, ok := FindProjectRoot(filepath.Join(dir, "ws", "test", "models"), filepath.Join(dir, "ws")); ok {
+		t.Errorf("FindProjectRoot() = %q above the limit, want none", got)
+	}
+	if got, ok := FindProjectRoot(filepath.Join(dir, "ws", "test", "models"), dir); !ok || got != dir {
+		t.Errorf("FindProjectRoot() = %q, %v, want %q", got, ok, dir)
+	}
+}
+
+func TestResolveFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "Gemfile"), "")
+	writeFile(t, filepath.Join(dir, "app", "models", "user.rb"), "")
+	writeFile(t, filepath.Join(dir, "engines", "billing", "billing.gemspec"), "")
+	writeFile(t, filepath.Join(dir, "engines", "billing", "lib", "billing", "invoice.rb"), "")
+	workspace := t.TempDir()
+	writeFile(t, filepath.Join(workspace, "shop", "Gemfile"), "")
+	writeFile(t, filepath.Join(workspace, "shop", "app", "models", "user.rb"), "")
+
+	tests := []struct {
+		name         string
+		root         string
+		path         string
+		expectedRoot string
+		expectedPath string
+	}{
+		{
+			name:         "relative path at the root",
+			root:         dir,
+			path:         "app/models/user.rb",
+			expectedRoot: dir,
+			expectedPath: "app/models/user.rb",
+		},
+		{
+			name:         "absolute path",
+			root:         dir,
+			path:         filepath.Join(dir, "app", "models", "user.rb"),
+			expectedRoot: dir,
+			expectedPath: "app/models/user.rb",
+		},
+		{
+			name:         "absolute path outside the root",
+			root:         t.TempDir(),
+			path:         filepath.Join(dir, "app", "models", "user.rb"),
+			expectedRoot: dir,
+			expectedPath: "app/models/user.rb",
+		},
+		{
+			name:         "root without markers below a project",
+			root:         filepath.Join(dir, "app"),
+			path:         "models/user.rb",
+			expectedRoot: filepath.Join(dir, "app"),
+			expectedPath: "models/user.rb",
+		},
+		{
+			name:         "worktree above the ruby project",
+			root:         workspace,
+			path:         "shop/app/models/user.rb",
+			expectedRoot: filepath.Join(workspace, "shop"),
+			expectedPath: "app/models/user.rb",
+		},
+		{
+			name:         "engine within the project",
+			root:         dir,
+			path:         "engines/billing/lib/billing/invoice.rb",
+			expectedRoot: dir,
+			expectedPath: "engines/billing/lib/billing/invoice.rb",
+		},
+		{
+			name:         "engine as the root",
+			root:         filepath.Join(dir, "engines", "billing"),
+			path:         "lib/billing/invoice.rb",
+			expectedRoot: filepath.Join(dir, "engines", "billing"),
+			expectedPath: "lib/billing/invoice.rb",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, path, err := ResolveFile(tt.root, tt.path)
+			if err != nil {
+				t.Fatalf("ResolveFile() unexpected error: %v", err)
+			}
+			if root != tt.expectedRoot || path != tt.expectedPath {
+				t.Errorf("ResolveFile() = (%q, %q), want (%q, %q)", root, path, tt.expectedRoot, tt.expectedPath)
+			}
+		})
+	}
+}
+
+func TestCLI_RunLookupStopsAtRoot(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "Gemfile"), "")
+	ws := filepath.Join(dir, "ws")
+	writeFile(t, filepath.Join(ws, "test", "models", "a_test.rb"), "")
+
+	var out bytes.Buffer
+	cli := &CLI{Command: "lookup", Root: ws, Path: "app/models/a.rb", Print: true, Stdout: &out}
+	if err := cli.Run(); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if got, want := out.String(), filepath.Join(ws, "test", "models", "a_test.rb")+"\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestCLI_RunLookupInEngine(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".rspec"), "")
+	writeFile(t, filepath.Join(dir, "spec", "spec_helper.rb"), "")
+	writeFile(t, filepath.Join(dir, ConfigFileName), `{"editor": "vim", "controller_tests": ["controller", "request"]}`)
+	engine := filepath.Join(dir, "engines", "eng")
+	writeFile(t, filepath.Join(engine, "eng.gemspec"), "")
+	writeFile(t, filepath.Join(engine, "lib", "eng", "engine.rb"), "")
+	writeFile(t, filepath.Join(engine, "app", "models", "widget.rb"), "")
+	writeFile(t, filepath.Join(engine, "spec", "models", "widget_spec.rb"), "")
+
+	var out bytes.Buffer
+	cli := &CLI{Command: "lookup", Root: dir, Path: "engines/eng/app/models/widget.rb", Print: true, Stdout: &out}
+	if err := cli.Run(); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if got, want := out.String(), filepath.Join(engine, "spec", "models", "widget_spec.rb")+"\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	// The engine follows the enclosing app's framework and configuration
+	root, path, err := ResolveFile(dir, "engines/eng/app/models/widget.rb")
+	if err != nil {
+		t.Fatalf("ResolveFile() unexpected error: %v", err)
+	}
+	project := NewProject(root)
+	scoped, _ := NewSourceFile(path, project).scoped()
+	if scoped.Project.Parent != project {
+		t.Fatalf("engine project has no parent")
+	}
+	if got := scoped.Project.Framework(); got != RSpec {
+		t.Errorf("engine Framework() = %q, want %q", got, RSpec)
+	}
+	if got := scoped.Project.ControllerTests(); !equalStrings(got, []string{"controller", "request"}) {
+		t.Errorf("engine ControllerTests() = %v, want the app's configuration", got)
+	}
+	if project.Config.Editor != "vim" {
+		t.Errorf("Config.Editor = %q, want the app's configuration", project.Config.Editor)
+	}
+}
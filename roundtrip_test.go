@@ -0,0 +1,146 @@
+package main
+
+import (
+	"math/rand"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+// trickySegments are directory and file names that contain, or are
+// contained in, the names of the source and test roots
+var trickySegments = []string{
+	"app", "apps", "application", "my_app", "app_lib",
+	"lib", "libs", "library", "spec", "specs", "rb", "rbac", "models",
+	"requests", "controllers", "user",
+}
+
+// layout is a generated project with a single source file
+type layout struct {
+	Gem    bool
+	RSpec  bool
+	Prefix bool
+	Source string
+}
+
+// Generate implements quick.Generator
+func (layout) Generate(r *rand.Rand, size int) reflect.Value {
+	l := layout{Gem: r.Intn(2) == 0, RSpec: r.Intn(2) == 0}
+	l.Prefix = !l.RSpec && r.Intn(2) == 0
+
+	var segments []string
+	switch {
+	case l.Gem && r.Intn(4) == 0:
+		// A file at the root of the gem
+	case l.Gem:
+		segments = append(segments, "lib")
+	case r.Intn(3) == 0:
+		segments = append(segments, "lib")
+	default:
+		kinds := []string{"models", "controllers", "services", "lib"}
+		segments = append(segments, "app", kinds[r.Intn(len(kinds))])
+	}
+	for i := r.Intn(3); i > 0; i-- {
+		segments = append(segments, trickySegments[r.Intn(len(trickySegments))])
+	}
+
+	base := trickySegments[r.Intn(len(trickySegments))]
+	if len(segments) >= 2 && segments[0] == "app" && segments[1] == "controllers" {
+		base += "_controller"
+	}
+	l.Source = path.Join(append(segments, base+".rb")...)
+	return reflect.ValueOf(l)
+}
+
+// roundTrips creates the layout and its preferred test file, and reports
+// whether toggling from either file leads to the other
+func (l layout) roundTrips(t *testing.T) bool {
+	t.Helper()
+	dir := t.TempDir()
+	if l.Gem {
+		writeFile(t, filepath.Join(dir, "sample.gemspec"), "")
+	} else {
+		writeFile(t, filepath.Join(dir, "config", "application.rb"), "")
+	}
+	if l.RSpec {
+		writeFile(t, filepath.Join(dir, ".rspec"), "")
+	} else {
+		writeFile(t, filepath.Join(dir, "test", "test_helper.rb"), "")
+	}
+	if l.Prefix {
+		writeFile(t, filepath.Join(dir, ConfigFileName), `{"test_naming": "prefix"}`)
+	}
+	writeFile(t, filepath.Join(dir, filepath.FromSlash(l.Source)), "")
+
+	project := NewProject(dir)
+	testPath := NewSourceFile(l.Source, project).PreferredTestPath()
+	if testPath == "" {
+		t.Logf("%+v: no test path", l)
+		return false
+	}
+	writeFile(t, filepath.Join(dir, filepath.FromSlash(testPath)), "")
+
+	forward := NewSourceFile(l.Source, project).AlternateFile()
+	back := NewSourceFile(testPath, project).AlternateFile()
+	if forward != filepath.Join(dir, filepath.FromSlash(testPath)) || back != filepath.Join(dir, filepath.FromSlash(l.Source)) {
+		t.Logf("%+v: %s -> %s, %s -> %s", l, l.Source, forward, testPath, back)
+		return false
+	}
+	return true
+}
+
+func TestToggleRoundTrip(t *testing.T) {
+	property := func(l layout) bool { return l.roundTrips(t) }
+	if err := quick.Check(property, &quick.Config{MaxCount: 300}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestToggleRoundTrip_Examples(t *testing.T) {
+	tests := []layout{
+		{Gem: true, RSpec: true, Source: "lib/applications/app.rb"},
+		{Gem: false, RSpec: true, Source: "app/models/rbac.rb"},
+		{Gem: false, RSpec: true, Source: "app/models/app/lib.rb"},
+		{Gem: false, RSpec: false, Source: "app/services/my_app/spec.rb"},
+		{Gem: true, RSpec: false, Source: "lib/lib/app.rb"},
+		{Gem: true, RSpec: true, Source: "application.rb"},
+		{Gem: false, RSpec: true, Source: "lib/apps/rb.rb"},
+		{Gem: false, RSpec: false, Prefix: true, Source: "app/models/user.rb"},
+		{Gem: true, RSpec: false, Prefix: true, Source: "lib/tc.rb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.ReplaceAll(tt.Source, "/", "_"), func(t *testing.T) {
+			if !tt.roundTrips(t) {
+				t.Errorf("%s does not round trip", tt.Source)
+			}
+		})
+	}
+}
+
+func TestReplacePathPrefix(t *testing.T) {
+	tests := []struct {
+		path     string
+		from     string
+		to       string
+		expected string
+		ok       bool
+	}{
+		{"app/models/user.rb", "app", "spec", "spec/models/user.rb", true},
+		{"apps/models/user.rb", "app", "spec", "", false},
+		{"lib/my_app/app.rb", "app", "spec", "", false},
+		{"spec/lib/foo_spec.rb", "spec/lib", "lib", "lib/foo_spec.rb", true},
+		{"foo.rb", "", "spec", "spec/foo.rb", true},
+		{"spec/foo_spec.rb", "spec", "", "foo_spec.rb", true},
+	}
+
+	for _, tt := range tests {
+		got, ok := replacePathPrefix(tt.path, tt.from, tt.to)
+		if got != tt.expected || ok != tt.ok {
+			t.Errorf("replacePathPrefix(%q, %q, %q) = %q, %v, want %q, %v", tt.path, tt.from, tt.to, got, ok, tt.expected, tt.ok)
+		}
+	}
+}
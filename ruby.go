@@ -0,0 +1,241 @@
+package main
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+// BlockKind identifies the kind of Ruby construct found by the scanner
+type BlockKind string
+
+const (
+	// BlockClass is a class or module definition, or a class << self block
+	BlockClass BlockKind = "class"
+	// BlockDef is a method definition
+	BlockDef BlockKind = "def"
+	// BlockDescribe is an RSpec describe or context group
+	BlockDescribe BlockKind = "describe"
+	// BlockExample is an RSpec it/specify example
+	BlockExample BlockKind = "example"
+	// BlockTest is a Rails test "..." do block; test_ methods are BlockDef
+	BlockTest BlockKind = "test"
+)
+
+// RubyBlock is a block-opening construct and the lines it spans (1-based).
+// Singleton marks class methods, defined with def self. or within a
+// class << self block, and such blocks themselves.
+type RubyBlock struct {
+	Kind      BlockKind
+	Name      string
+	Line      int
+	EndLine   int
+	Indent    int
+	Singleton bool
+}
+
+// Contains reports whether the block spans the given line
+func (b RubyBlock) Contains(line int) bool {
+	return line >= b.Line && line <= b.EndLine
+}
+
+var (
+	classRegex     = regexp.MustCompile(`^(?:class|module)\s+([A-Z][\w:]*)`)
+	singletonRegex = regexp.MustCompile(`^class\s*<<\s*self\b`)
+	defRegex       = regexp.MustCompile(`^def\s+(self\.)?([\w]+[?!=]?|\[\]=?|[-+*/%<>=!~^&|]+)`)
+	describeRegex  = regexp.MustCompile(`^(?:RSpec\.)?(?:describe|context|feature)\s*\(?\s*(?:(['"])(.*?)['"]|([A-Z][\w:]*))`)
+	exampleRegex   = regexp.MustCompile(`^(?:it|specify|scenario|example)\b\s*\(?\s*(?:(['"])(.*?)['"])?`)
+	testBlockRegex = regexp.MustCompile(`^test\s*\(?\s*(['"])(.*?)['"]`)
+	endRegex       = regexp.MustCompile(`^end\b`)
+	// endlessDefRegex requires a space before "=" so setters aren't matched
+	endlessDefRegex = regexp.MustCompile(`^def\s+(self\.)?[^\s(]+(\([^)]*\))?\s+=\s`)
+)
+
+// ScanRubyFile reads and scans a Ruby file
+func ScanRubyFile(path string) ([]RubyBlock, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ScanRuby(string(data)), nil
+}
+
+// ScanRuby finds classes, methods, example groups and tests in Ruby
+// source. It relies on conventional indentation: a block ends at the
+// first "end" at the same indentation as its opening line.
+func ScanRuby(source string) []RubyBlock {
+	lines := strings.Split(source, "\n")
+	var blocks []RubyBlock
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+
+		block, ok := scanBlockLine(trimmed)
+		if !ok {
+			continue
+		}
+		block.Line = i + 1
+		block.Indent = indent
+		block.EndLine = findBlockEnd(lines, i, indent, trimmed)
+		blocks = append(blocks, block)
+	}
+
+	// Methods defined within class << self are class methods
+	for i, block := range blocks {
+		if block.Kind != BlockDef {
+			continue
+		}
+		if class, ok := EnclosingBlock(blocks[:i], block.Line, BlockClass); ok && class.Singleton {
+			blocks[i].Singleton = true
+		}
+	}
+	return blocks
+}
+
+// scanBlockLine recognizes a block-opening line
+func scanBlockLine(trimmed string) (RubyBlock, bool) {
+	if singletonRegex.MatchString(trimmed) {
+		return RubyBlock{Kind: BlockClass, Name: "<< self", Singleton: true}, true
+	}
+	if m := classRegex.FindStringSubmatch(trimmed); m != nil {
+		return RubyBlock{Kind: BlockClass, Name: m[1]}, true
+	}
+	if m := defRegex.FindStringSubmatch(trimmed); m != nil {
+		return RubyBlock{Kind: BlockDef, Name: m[2], Singleton: m[1] != ""}, true
+	}
+	if m := describeRegex.FindStringSubmatch(trimmed); m != nil {
+		name := m[2]
+		if m[1] == "" {
+			name = m[3]
+		}
+		return RubyBlock{Kind: BlockDescribe, Name: name}, true
+	}
+	if m := testBlockRegex.FindStringSubmatch(trimmed); m != nil {
+		return RubyBlock{Kind: BlockTest, Name: "test_" + strings.ReplaceAll(m[2], " ", "_")}, true
+	}
+	if m := exampleRegex.FindStringSubmatch(trimmed); m != nil {
+		return RubyBlock{Kind: BlockExample, Name: m[2]}, true
+	}
+	return RubyBlock{}, false
+}
+
+// findBlockEnd returns the 1-based line closing the block opened at
+// index start. One-line blocks end on their opening line.
+func findBlockEnd(lines []string, start, indent int, opening string) int {
+	if isOneLiner(opening) {
+		return start + 1
+	}
+	for j := start + 1; j < len(lines); j++ {
+		line := lines[j]
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		lineIndent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if lineIndent < indent {
+			return j
+		}
+		if lineIndent == indent && (endRegex.MatchString(trimmed) || strings.HasPrefix(trimmed, "}")) {
+			return j + 1
+		}
+	}
+	return len(lines)
+}
+
+// isOneLiner reports whether an opening line also closes its block, as in
+// "def foo; end", an endless "def foo = 1" or "it { is_expected.to be }"
+func isOneLiner(opening string) bool {
+	if strings.HasSuffix(opening, " end") || strings.HasSuffix(opening, ";end") {
+		return true
+	}
+	if endlessDefRegex.MatchString(opening) {
+		return true
+	}
+	return strings.Contains(opening, "{") && strings.HasSuffix(opening, "}")
+}
+
+// EnclosingBlock returns the innermost block of one of the given kinds
+// that contains the line
+func EnclosingBlock(blocks []RubyBlock, line int, kinds ...BlockKind) (RubyBlock, bool) {
+	var found RubyBlock
+	ok := false
+	for _, block := range blocks {
+		if !block.Contains(line) || !containsKind(kinds, block.Kind) {
+			continue
+		}
+		// Blocks are ordered by line, so a later match is nested deeper
+		found = block
+		ok = true
+	}
+	return found, ok
+}
+
+// EnclosingTest returns the innermost Minitest test, either a test_
+// method or a Rails test block, that contains the line
+func EnclosingTest(blocks []RubyBlock, line int) (RubyBlock, bool) {
+	var found RubyBlock
+	ok := false
+	for _, block := range blocks {
+		if block.Contains(line) && block.IsTest() {
+			found = block
+			ok = true
+		}
+	}
+	return found, ok
+}
+
+// IsTest reports whether the block is a Minitest test
+func (b RubyBlock) IsTest() bool {
+	return b.Kind == BlockTest || (b.Kind == BlockDef && strings.HasPrefix(b.Name, "test_"))
+}
+
+// DescribeName returns the RSpec group name conventionally used for a
+// method: "#name" for instance methods and ".name" for class methods
+func (b RubyBlock) DescribeName() string {
+	if b.Singleton {
+		return "." + b.Name
+	}
+	return "#" + b.Name
+}
+
+// FindDescribe returns the first describe or context block with the name
+func FindDescribe(blocks []RubyBlock, name string) (RubyBlock, bool) {
+	for _, block := range blocks {
+		if block.Kind == BlockDescribe && block.Name == name {
+			return block, true
+		}
+	}
+	return RubyBlock{}, false
+}
+
+// MethodTest returns the test block covering a method: its describe
+// '#method' (or '.method') group in a spec, or the first Minitest test
+// whose name mentions it
+func MethodTest(testBlocks []RubyBlock, method RubyBlock, rspec bool) (RubyBlock, bool) {
+	if rspec {
+		return FindDescribe(testBlocks, method.DescribeName())
+	}
+	for _, block := range testBlocks {
+		if block.IsTest() && strings.Contains(block.Name, method.TestedName()) {
+			return block, true
+		}
+	}
+	return RubyBlock{}, false
+}
+
+// TestedName returns the method name as Minitest test names mention it,
+// without a trailing ?, ! or =
+func (b RubyBlock) TestedName() string {
+	return strings.TrimRight(b.Name, "?!=")
+}
+
+// containsKind reports whether kinds contains kind
+func containsKind(kinds []BlockKind, kind BlockKind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,200 @@
+package main
+
+import "testing"
+
+func TestScanRuby(t *testing.T) {
+	source := `module Billing
+  class Invoice
+    def self.build(attrs = {})
+      new(attrs)
+    end
+
+    def total = items.sum
+
+    def total=(value)
+      @total = value
+    end
+
+    def charge!; end
+
+    def ==(other)
+      other.id == id
+    end
+  end
+end
+`
+	expected := []RubyBlock{
+		{Kind: BlockClass, Name: "Billing", Line: 1, EndLine: 19, Indent: 0},
+		{Kind: BlockClass, Name: "Invoice", Line: 2, EndLine: 18, Indent: 2},
+		{Kind: BlockDef, Name: "build", Line: 3, EndLine: 5, Indent: 4, Singleton: true},
+		{Kind: BlockDef, Name: "total", Line: 7, EndLine: 7, Indent: 4},
+		{Kind: BlockDef, Name: "total=", Line: 9, EndLine: 11, Indent: 4},
+		{Kind: BlockDef, Name: "charge!", Line: 13, EndLine: 13, Indent: 4},
+		{Kind: BlockDef, Name: "==", Line: 15, EndLine: 17, Indent: 4},
+	}
+
+	got := ScanRuby(source)
+	if len(got) != len(expected) {
+		t.Fatalf("ScanRuby() found %d blocks, want %d: %+v", len(got), len(expected), got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("block %d = %+v, want %+v", i, got[i], expected[i])
+		}
+	}
+}
+
+func TestScanRuby_SingletonClass(t *testing.T) {
+	source := `class Session
+  class << self
+    def find_by_token(token)
+      new
+    end
+  end
+
+  def expire
+  end
+end
+`
+	expected := []RubyBlock{
+		{Kind: BlockClass, Name: "Session", Line: 1, EndLine: 10, Indent: 0},
+		{Kind: BlockClass, Name: "<< self", Line: 2, EndLine: 6, Indent: 2, Singleton: true},
+		{Kind: BlockDef, Name: "find_by_token", Line: 3, EndLine: 5, Indent: 4, Singleton: true},
+		{Kind: BlockDef, Name: "expire", Line: 8, EndLine: 9, Indent: 2},
+	}
+
+	got := ScanRuby(source)
+	if len(got) != len(expected) {
+		t.Fatalf("ScanRuby() found %d blocks, want %d: %+v", len(got), len(expected), got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("block %d = %+v, want %+v", i, got[i], expected[i])
+		}
+	}
+	if name := got[2].DescribeName(); name != ".find_by_token" {
+		t.Errorf("DescribeName() = %q, want %q", name, ".find_by_token")
+	}
+}
+
+func TestScanRuby_Specs(t *testing.T) {
+	source := `RSpec.describe Billing::Invoice do
+  describe "#charge!" do
+    context 'when paid' do
+      it "is a no-op" do
+      end
+
+      it { is_expected.to be_paid }
+    end
+  end
+
+  test "legacy block" do
+  end
+end
+`
+	expected := []RubyBlock{
+		{Kind: BlockDescribe, Name: "Billing::Invoice", Line: 1, EndLine: 13, Indent: 0},
+		{Kind: BlockDescribe, Name: "#charge!", Line: 2, EndLine: 9, Indent: 2},
+		{Kind: BlockDescribe, Name: "when paid", Line: 3, EndLine: 8, Indent: 4},
+		{Kind: BlockExample, Name: "is a no-op", Line: 4, EndLine: 5, Indent: 6},
+		{Kind: BlockExample, Name: "", Line: 7, EndLine: 7, Indent: 6},
+		{Kind: BlockTest, Name: "test_legacy_block", Line: 11, EndLine: 12, Indent: 2},
+	}
+
+	got := ScanRuby(source)
+	if len(got) != len(expected) {
+		t.Fatalf("ScanRuby() found %d blocks, want %d: %+v", len(got), len(expected), got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("block %d = %+v, want %+v", i, got[i], expected[i])
+		}
+	}
+}
+
+func TestEnclosingBlock(t *testing.T) {
+	blocks := ScanRuby(`class Invoice
+  def charge!
+    pay
+  end
+end
+`)
+
+	tests := []struct {
+		name     string
+		line     int
+		kinds    []BlockKind
+		expected string
+		found    bool
+	}{
+		{name: "inside method", line: 3, kinds: []BlockKind{BlockDef}, expected: "charge!", found: true},
+		{name: "innermost of several kinds", line: 3, kinds: []BlockKind{BlockClass, BlockDef}, expected: "charge!", found: true},
+		{name: "class body", line: 1, kinds: []BlockKind{BlockClass, BlockDef}, expected: "Invoice", found: true},
+		{name: "outside method", line: 5, kinds: []BlockKind{BlockDef}, found: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := EnclosingBlock(blocks, tt.line, tt.kinds...)
+			if ok != tt.found {
+				t.Fatalf("EnclosingBlock() found = %v, want %v", ok, tt.found)
+			}
+			if ok && got.Name != tt.expected {
+				t.Errorf("EnclosingBlock() = %q, want %q", got.Name, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRubyBlock_DescribeName(t *testing.T) {
+	if got := (RubyBlock{Kind: BlockDef, Name: "charge!"}).DescribeName(); got != "#charge!" {
+		t.Errorf("DescribeName() = %q, want %q", got, "#charge!")
+	}
+	if got := (RubyBlock{Kind: BlockDef, Name: "build", Singleton: true}).DescribeName(); got != ".build" {
+		t.Errorf("DescribeName() = %q, want %q", got, ".build")
+	}
+}
+
+func TestMethodTest(t *testing.T) {
+	spec := ScanRuby(`RSpec.describe Invoice do
+  describe "#charge!" do
+    it "charges" do
+    end
+  end
+end
+`)
+	minitest := ScanRuby(`class InvoiceTest < Minitest::Test
+  def test_total
+  end
+
+  def test_charge_twice
+  end
+end
+`)
+
+	tests := []struct {
+		name     string
+		blocks   []RubyBlock
+		method   RubyBlock
+		rspec    bool
+		expected int
+		found    bool
+	}{
+		{name: "describe group", blocks: spec, method: RubyBlock{Kind: BlockDef, Name: "charge!"}, rspec: true, expected: 2, found: true},
+		{name: "no describe group", blocks: spec, method: RubyBlock{Kind: BlockDef, Name: "total"}, rspec: true, found: false},
+		{name: "minitest test", blocks: minitest, method: RubyBlock{Kind: BlockDef, Name: "charge!"}, expected: 5, found: true},
+		{name: "no minitest test", blocks: minitest, method: RubyBlock{Kind: BlockDef, Name: "refund"}, found: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := MethodTest(tt.blocks, tt.method, tt.rspec)
+			if ok != tt.found {
+				t.Fatalf("MethodTest() found = %v, want %v", ok, tt.found)
+			}
+			if ok && got.Line != tt.expected {
+				t.Errorf("MethodTest() line = %d, want %d", got.Line, tt.expected)
+			}
+		})
+	}
+}
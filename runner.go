@@ -0,0 +1,237 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Framework identifies a Ruby test framework
+type Framework string
+
+const (
+	// RSpec is the RSpec framework (spec/ directory, _spec.rb files)
+	RSpec Framework = "rspec"
+	// Minitest is Minitest or Test::Unit (test/ directory, _test.rb files)
+	Minitest Framework = "minitest"
+)
+
+// Framework returns the project's preferred test framework: the
+// configured one, otherwise RSpec when there are RSpec clues. A package
+// without clues of its own follows its enclosing project.
+func (p *Project) Framework() Framework {
+	if p.framework != "" {
+		return p.framework
+	}
+	if framework := p.config().Framework; framework != "" {
+		return Framework(framework)
+	}
+	if p.SpecClue() != "" {
+		return RSpec
+	}
+	if p.Parent == nil || p.MinitestClue() != "" {
+		return Minitest
+	}
+	return p.Parent.Framework()
+}
+
+// Frameworks returns the test frameworks used by the project, the
+// preferred one first. Projects migrating between frameworks have both a
+// spec/ and a test/ tree.
+func (p *Project) Frameworks() []Framework {
+	preferred := p.Framework()
+	if p.framework != "" {
+		return []Framework{preferred}
+	}
+
+	frameworks := []Framework{preferred}
+	switch {
+	case preferred == RSpec && p.MinitestClue() != "":
+		frameworks = append(frameworks, Minitest)
+	case preferred == Minitest && p.SpecClue() != "":
+		frameworks = append(frameworks, RSpec)
+	}
+	return frameworks
+}
+
+// frameworkViews returns the project restricted to each of its
+// frameworks, the preferred one first
+func (p *Project) frameworkViews() []*Project {
+	frameworks := p.Frameworks()
+	if len(frameworks) == 1 {
+		return []*Project{p}
+	}
+
+	var views []*Project
+	for _, framework := range frameworks {
+		view := *p
+		view.framework = framework
+		// Packages of a view follow its framework, don't share them
+		view.packages = nil
+		views = append(views, &view)
+	}
+	return views
+}
+
+// FrameworkFor returns the framework a test file belongs to, judged by its
+// name, or the preferred framework when no framework claims it. A file in
+// a package is judged by the package.
+func (p *Project) FrameworkFor(file string) Framework {
+	if pkg, dir := p.Package(file); pkg != nil {
+		return pkg.FrameworkFor(strings.TrimPrefix(file, dir+"/"))
+	}
+	for _, project := range p.frameworkViews() {
+		if _, ok := project.Untestify(file); ok {
+			return project.Framework()
+		}
+	}
+	return p.Framework()
+}
+
+// TestTarget is a test file, optionally narrowed down to a single example
+type TestTarget struct {
+	// File is the test file, relative to the project root
+	File string
+	// Line selects the RSpec example or group at that line (0 for all)
+	Line int
+	// Name is a Minitest -n filter, an exact name or a /regex/ (empty for all)
+	Name string
+}
+
+// TestCommand returns the command line that runs the test target from
+// the project root. Binstubs are preferred over bundle exec, which is
+// preferred over a bare executable.
+func (p *Project) TestCommand(target TestTarget) []string {
+	bundled := fileExists(filepath.Join(p.Root, "Gemfile"))
+
+	if p.FrameworkFor(target.File) == RSpec {
+		location := target.File
+		if target.Line > 0 {
+			location = fmt.Sprintf("%s:%d", target.File, target.Line)
+		}
+		switch {
+		case fileExists(filepath.Join(p.Root, "bin", "rspec")):
+			return []string{"bin/rspec", location}
+		case bundled:
+			return []string{"bundle", "exec", "rspec", location}
+		default:
+			return []string{"rspec", location}
+		}
+	}
+
+	var args []string
+	switch {
+	case fileExists(filepath.Join(p.Root, "bin", "rails")):
+		args = []string{"bin/rails", "test", target.File}
+	case bundled:
+		args = []string{"bundle", "exec", "ruby", "-Itest", target.File}
+	default:
+		args = []string{"ruby", "-Itest", target.File}
+	}
+	if target.Name != "" {
+		args = append(args, "-n", target.Name)
+	}
+	return args
+}
+
+// RunTests runs the test target from the project root, or from the root
+// of the package owning it when the package can run its own tests,
+// streaming its output. A failing run is reported as an *exec.ExitError.
+func (p *Project) RunTests(target TestTarget) error {
+	runner, target := p.runner(target)
+	args := runner.TestCommand(target)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = runner.Root
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// runner returns the project to run the test target from, with the
+// target relative to its root: the innermost package owning the test file
+// when it has a Gemfile or binstubs of its own, as engines and gems do,
+// otherwise the project itself
+func (p *Project) runner(target TestTarget) (*Project, TestTarget) {
+	pkg, dir := p.Package(target.File)
+	if pkg == nil {
+		return p, target
+	}
+	if !fileExists(filepath.Join(pkg.Root, "Gemfile")) && !fileExists(filepath.Join(pkg.Root, "bin")) {
+		return p, target
+	}
+	target.File = strings.TrimPrefix(target.File, dir+"/")
+	return pkg, target
+}
+
+// TestFile returns the test file to run for this file, relative to the
+// project root: the file itself if it is a test, otherwise its alternate
+func (s *SourceFile) TestFile() (string, error) {
+	if s.IsTestFile() {
+		return s.Filename, nil
+	}
+
+	alternate := s.AlternateFile()
+	if alternate == "" {
+		return "", fmt.Errorf("no test file found for %s", s.Filename)
+	}
+	return filepath.Rel(s.Project.Root, alternate)
+}
+
+// TestTarget returns the tests to run for the cursor line (0 for the
+// whole file). In a test file it selects the enclosing example or test.
+// In a source file it maps the enclosing method to its describe '#method'
+// group in the spec, or to the Minitest tests named after it.
+func (s *SourceFile) TestTarget(line int) (TestTarget, error) {
+	testFile, err := s.TestFile()
+	if err != nil {
+		return TestTarget{}, err
+	}
+	target := TestTarget{File: testFile}
+	if line <= 0 {
+		return target, nil
+	}
+
+	framework := s.Project.FrameworkFor(testFile)
+	if s.IsTestFile() {
+		if framework == RSpec {
+			target.Line = line
+			return target, nil
+		}
+		blocks, err := ScanRubyFile(filepath.Join(s.Project.Root, testFile))
+		if err != nil {
+			return TestTarget{}, err
+		}
+		if test, ok := EnclosingTest(blocks, line); ok {
+			target.Name = test.Name
+		}
+		return target, nil
+	}
+
+	srcBlocks, err := ScanRubyFile(filepath.Join(s.Project.Root, s.Filename))
+	if err != nil {
+		return TestTarget{}, err
+	}
+	method, ok := EnclosingBlock(srcBlocks, line, BlockDef)
+	if !ok {
+		return target, nil
+	}
+
+	testBlocks, err := ScanRubyFile(filepath.Join(s.Project.Root, testFile))
+	if err != nil {
+		return TestTarget{}, err
+	}
+	test, ok := MethodTest(testBlocks, method, framework == RSpec)
+	if !ok {
+		return target, nil
+	}
+	if framework == RSpec {
+		target.Line = test.Line
+	} else {
+		target.Name = "/" + regexp.QuoteMeta(method.TestedName()) + "/"
+	}
+	return target, nil
+}
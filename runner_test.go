@@ -0,0 +1,356 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestProject_TestCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []string
+		expected []string
+	}{
+		{
+			name:     "rspec binstub",
+			files:    []string{".rspec", "Gemfile", "bin/rspec"},
+			expected: []string{"bin/rspec", "spec/user_spec.rb"},
+		},
+		{
+			name:     "rspec with bundler",
+			files:    []string{".rspec", "Gemfile"},
+			expected: []string{"bundle", "exec", "rspec", "spec/user_spec.rb"},
+		},
+		{
+			name:     "bare rspec",
+			files:    []string{".rspec"},
+			expected: []string{"rspec", "spec/user_spec.rb"},
+		},
+		{
+			name:     "rails minitest",
+			files:    []string{"Gemfile", "bin/rails"},
+			expected: []string{"bin/rails", "test", "spec/user_spec.rb"},
+		},
+		{
+			name:     "minitest with bundler",
+			files:    []string{"Gemfile"},
+			expected: []string{"bundle", "exec", "ruby", "-Itest", "spec/user_spec.rb"},
+		},
+		{
+			name:     "bare minitest",
+			files:    nil,
+			expected: []string{"ruby", "-Itest", "spec/user_spec.rb"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, file := range tt.files {
+				writeFile(t, filepath.Join(dir, file), "")
+			}
+
+			project := NewProject(dir)
+			if got := project.TestCommand(TestTarget{File: "spec/user_spec.rb"}); !equalStrings(got, tt.expected) {
+				t.Errorf("TestCommand() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProject_TestCommandNarrowed(t *testing.T) {
+	rspecDir := t.TempDir()
+	writeFile(t, filepath.Join(rspecDir, ".rspec"), "")
+	got := NewProject(rspecDir).TestCommand(TestTarget{File: "spec/user_spec.rb", Line: 12})
+	if expected := []string{"rspec", "spec/user_spec.rb:12"}; !equalStrings(got, expected) {
+		t.Errorf("TestCommand() = %v, want %v", got, expected)
+	}
+
+	railsDir := t.TempDir()
+	writeFile(t, filepath.Join(railsDir, "bin", "rails"), "")
+	got = NewProject(railsDir).TestCommand(TestTarget{File: "test/user_test.rb", Name: "test_valid"})
+	if expected := []string{"bin/rails", "test", "test/user_test.rb", "-n", "test_valid"}; !equalStrings(got, expected) {
+		t.Errorf("TestCommand() = %v, want %v", got, expected)
+	}
+}
+
+func TestProject_Frameworks(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []string
+		config   string
+		expected []Framework
+	}{
+		{
+			name:     "rspec only",
+			files:    []string{"spec/spec_helper.rb"},
+			expected: []Framework{RSpec},
+		},
+		{
+			name:     "minitest only",
+			files:    []string{"test/test_helper.rb"},
+			expected: []Framework{Minitest},
+		},
+		{
+			name:     "both",
+			files:    []string{"spec/spec_helper.rb", "test/test_helper.rb"},
+			expected: []Framework{RSpec, Minitest},
+		},
+		{
+			name:     "both preferring minitest",
+			files:    []string{"spec/spec_helper.rb", "test/test_helper.rb"},
+			config:   `{"framework": "minitest"}`,
+			expected: []Framework{Minitest, RSpec},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, file := range tt.files {
+				writeFile(t, filepath.Join(dir, file), "")
+			}
+			if tt.config != "" {
+				writeFile(t, filepath.Join(dir, ConfigFileName), tt.config)
+			}
+
+			got := NewProject(dir).Frameworks()
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Frameworks() = %v, want %v", got, tt.expected)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("Frameworks() = %v, want %v", got, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestProject_MixedFrameworks(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "spec", "spec_helper.rb"), "")
+	writeFile(t, filepath.Join(dir, "test", "test_helper.rb"), "")
+	writeFile(t, filepath.Join(dir, "app", "models", "user.rb"), "")
+	writeFile(t, filepath.Join(dir, "spec", "models", "user_spec.rb"), "")
+	writeFile(t, filepath.Join(dir, "test", "models", "user_test.rb"), "")
+	writeFile(t, filepath.Join(dir, "app", "models", "account.rb"), "")
+	writeFile(t, filepath.Join(dir, "test", "models", "account_test.rb"), "")
+	project := NewProject(dir)
+
+	result := NewSourceFile("app/models/user.rb", project).Lookup()
+	expected := []string{
+		filepath.Join(dir, "spec", "models", "user_spec.rb"),
+		filepath.Join(dir, "test", "models", "user_test.rb"),
+	}
+	if !equalStrings(result.Alternates, expected) {
+		t.Errorf("Alternates = %v, want %v", result.Alternates, expected)
+	}
+
+	// Tests still in the old tree toggle both ways
+	if got, want := NewSourceFile("app/models/account.rb", project).AlternateFile(), filepath.Join(dir, "test", "models", "account_test.rb"); got != want {
+		t.Errorf("AlternateFile() = %q, want %q", got, want)
+	}
+	if got, want := NewSourceFile("test/models/account_test.rb", project).AlternateFile(), filepath.Join(dir, "app", "models", "account.rb"); got != want {
+		t.Errorf("AlternateFile() = %q, want %q", got, want)
+	}
+
+	// New tests go to the preferred framework
+	if got := NewSourceFile("app/models/order.rb", project).PreferredTestPath(); got != "spec/models/order_spec.rb" {
+		t.Errorf("PreferredTestPath() = %q, want %q", got, "spec/models/order_spec.rb")
+	}
+
+	// Each test file runs with its own framework
+	if got := project.TestCommand(TestTarget{File: "test/models/user_test.rb"}); !equalStrings(got, []string{"ruby", "-Itest", "test/models/user_test.rb"}) {
+		t.Errorf("TestCommand() = %v for a Minitest file", got)
+	}
+	if got := project.TestCommand(TestTarget{File: "spec/models/user_spec.rb"}); !equalStrings(got, []string{"rspec", "spec/models/user_spec.rb"}) {
+		t.Errorf("TestCommand() = %v for a spec file", got)
+	}
+}
+
+func TestSourceFile_TestFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".rspec"), "")
+	writeFile(t, filepath.Join(dir, "app", "models", "user.rb"), "")
+	writeFile(t, filepath.Join(dir, "spec", "models", "user_spec.rb"), "")
+	writeFile(t, filepath.Join(dir, "app", "models", "post.rb"), "")
+	project := NewProject(dir)
+
+	tests := []struct {
+		name      string
+		filename  string
+		expected  string
+		expectErr bool
+	}{
+		{name: "test file runs itself", filename: "spec/models/user_spec.rb", expected: "spec/models/user_spec.rb"},
+		{name: "source file runs its alternate", filename: "app/models/user.rb", expected: "spec/models/user_spec.rb"},
+		{name: "source file without test", filename: "app/models/post.rb", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewSourceFile(tt.filename, project).TestFile()
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("TestFile() expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("TestFile() unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("TestFile() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProject_RunTestsPropagatesExitCode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("binstub scripts require a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".rspec"), "")
+	writeFile(t, filepath.Join(dir, "bin", "rspec"), "#!/bin/sh\nexit 3\n")
+	if err := os.Chmod(filepath.Join(dir, "bin", "rspec"), 0755); err != nil {
+		t.Fatalf("chmod failed: %v", err)
+	}
+
+	err := NewProject(dir).RunTests(TestTarget{File: "spec/user_spec.rb"})
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("RunTests() error = %v, want *exec.ExitError", err)
+	}
+	if exitErr.ExitCode() != 3 {
+		t.Errorf("exit code = %d, want 3", exitErr.ExitCode())
+	}
+}
+
+func TestSourceFile_TestTarget(t *testing.T) {
+	source := `class Invoice
+  def self.build
+    new
+  end
+
+  def charge!
+    true
+  end
+end
+`
+	spec := `RSpec.describe Invoice do
+  describe ".build" do
+    it "builds" do
+    end
+  end
+
+  describe "#charge!" do
+    it "charges" do
+    end
+  end
+end
+`
+	minitest := `require "test_helper"
+
+class InvoiceTest < ActiveSupport::TestCase
+  test "charge! succeeds" do
+    assert true
+  end
+
+  def test_build
+    assert true
+  end
+end
+`
+
+	rspecDir := t.TempDir()
+	writeFile(t, filepath.Join(rspecDir, ".rspec"), "")
+	writeFile(t, filepath.Join(rspecDir, "app", "models", "invoice.rb"), source)
+	writeFile(t, filepath.Join(rspecDir, "spec", "models", "invoice_spec.rb"), spec)
+
+	minitestDir := t.TempDir()
+	writeFile(t, filepath.Join(minitestDir, "app", "models", "invoice.rb"), source)
+	writeFile(t, filepath.Join(minitestDir, "test", "models", "invoice_test.rb"), minitest)
+
+	tests := []struct {
+		name     string
+		dir      string
+		filename string
+		line     int
+		expected TestTarget
+	}{
+		{
+			name:     "whole file",
+			dir:      rspecDir,
+			filename: "app/models/invoice.rb",
+			expected: TestTarget{File: "spec/models/invoice_spec.rb"},
+		},
+		{
+			name:     "rspec example line",
+			dir:      rspecDir,
+			filename: "spec/models/invoice_spec.rb",
+			line:     8,
+			expected: TestTarget{File: "spec/models/invoice_spec.rb", Line: 8},
+		},
+		{
+			name:     "source instance method to describe",
+			dir:      rspecDir,
+			filename: "app/models/invoice.rb",
+			line:     7,
+			expected: TestTarget{File: "spec/models/invoice_spec.rb", Line: 7},
+		},
+		{
+			name:     "source class method to describe",
+			dir:      rspecDir,
+			filename: "app/models/invoice.rb",
+			line:     3,
+			expected: TestTarget{File: "spec/models/invoice_spec.rb", Line: 2},
+		},
+		{
+			name:     "source line outside any method",
+			dir:      rspecDir,
+			filename: "app/models/invoice.rb",
+			line:     1,
+			expected: TestTarget{File: "spec/models/invoice_spec.rb"},
+		},
+		{
+			name:     "minitest test block",
+			dir:      minitestDir,
+			filename: "test/models/invoice_test.rb",
+			line:     5,
+			expected: TestTarget{File: "test/models/invoice_test.rb", Name: "test_charge!_succeeds"},
+		},
+		{
+			name:     "minitest test method",
+			dir:      minitestDir,
+			filename: "test/models/invoice_test.rb",
+			line:     9,
+			expected: TestTarget{File: "test/models/invoice_test.rb", Name: "test_build"},
+		},
+		{
+			name:     "minitest source method",
+			dir:      minitestDir,
+			filename: "app/models/invoice.rb",
+			line:     6,
+			expected: TestTarget{File: "test/models/invoice_test.rb", Name: "/charge/"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewSourceFile(tt.filename, NewProject(tt.dir)).TestTarget(tt.line)
+			if err != nil {
+				t.Fatalf("TestTarget() unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("TestTarget() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	// visibilityRegex matches a bare private, protected or public line
+	// changing the visibility of the methods defined after it
+	visibilityRegex = regexp.MustCompile(`^(private|protected|public)\s*(?:#.*)?$`)
+	// visibilityArgsRegex matches `private :name, :other` style calls
+	visibilityArgsRegex = regexp.MustCompile(`^(private|protected|private_class_method)\s*\(?\s*(:.*)`)
+	// symbolRegex matches the method names given as symbols
+	symbolRegex = regexp.MustCompile(`:(\w+[?!=]?)`)
+	// testNameRegex matches method names usable in a test_ method name
+	testNameRegex = regexp.MustCompile(`^\w+[?!=]?$`)
+)
+
+// SyncResult describes the test blocks added by SyncTests
+type SyncResult struct {
+	// File is the test file, relative to the project root
+	File string
+	// Added lists the describe names or test methods added, in order
+	Added []string
+	// Line is the line of the first block added, or 0
+	Line int
+}
+
+// PublicMethods returns the public methods the source file defines, in
+// order. Methods following a bare private or protected, or named in a
+// `private :name` call, are left out, as is initialize. Class methods
+// are public unless named in private_class_method, or hidden the same
+// way within a class << self block.
+func (s *SourceFile) PublicMethods() ([]RubyBlock, error) {
+	data, err := os.ReadFile(filepath.Join(s.Project.Root, filepath.FromSlash(s.Filename)))
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(string(data), "\n")
+	blocks := ScanRuby(string(data))
+
+	// Names made non-public by symbol, instance and class methods apart
+	hidden := map[string]bool{}
+	hiddenClass := map[string]bool{}
+	for i, line := range lines {
+		m := visibilityArgsRegex.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		for _, symbol := range symbolRegex.FindAllStringSubmatch(m[2], -1) {
+			if m[1] == "private_class_method" || inSingletonClass(blocks, i+1) {
+				hiddenClass[symbol[1]] = true
+			} else {
+				hidden[symbol[1]] = true
+			}
+		}
+	}
+
+	var methods []RubyBlock
+	for _, block := range blocks {
+		if block.Kind != BlockDef {
+			continue
+		}
+		if block.Singleton {
+			if hiddenClass[block.Name] {
+				continue
+			}
+			if !inSingletonClass(blocks, block.Line) || visibilityAt(lines, blocks, block) == "public" {
+				methods = append(methods, block)
+			}
+			continue
+		}
+		if block.Name == "initialize" || hidden[block.Name] || isNestedDef(blocks, block) {
+			continue
+		}
+		if visibilityAt(lines, blocks, block) == "public" {
+			methods = append(methods, block)
+		}
+	}
+	return methods, nil
+}
+
+// inSingletonClass reports whether the line is directly within a
+// class << self block
+func inSingletonClass(blocks []RubyBlock, line int) bool {
+	class, ok := EnclosingBlock(blocks, line, BlockClass)
+	return ok && class.Singleton
+}
+
+// visibilityAt returns the visibility set by the last bare visibility
+// keyword before the method at its indentation, within its class or
+// class << self block
+func visibilityAt(lines []string, blocks []RubyBlock, method RubyBlock) string {
+	start := 0
+	if class, ok := EnclosingBlock(blocks, method.Line, BlockClass); ok {
+		start = class.Line
+	}
+	visibility := "public"
+	for i := start; i < method.Line-1; i++ {
+		line := lines[i]
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if m := visibilityRegex.FindStringSubmatch(strings.TrimSpace(line)); m != nil && indent == method.Indent {
+			visibility = m[1]
+		}
+	}
+	return visibility
+}
+
+// isNestedDef reports whether a method is defined within another method
+func isNestedDef(blocks []RubyBlock, method RubyBlock) bool {
+	for _, block := range blocks {
+		if block.Kind == BlockDef && block.Line < method.Line && block.Contains(method.Line) {
+			return true
+		}
+	}
+	return false
+}
+
+// SyncTests adds a pending skeleton to the alternate test for each public
+// method of the source file that has none: a describe '#method' (or
+// '.method') group with a pending example in a spec, or a test_method
+// skipping in a Minitest test. Skeletons go before the end of the top-level
+// describe block or test class; existing content is left untouched. From
+// a test file, it syncs that file with its source.
+func (s *SourceFile) SyncTests() (*SyncResult, error) {
+	sourceFile, testFile := s, ""
+	if s.IsTestFile() {
+		sourceFile = s.source()
+		if sourceFile == s {
+			return nil, fmt.Errorf("no source file found for %s", s.Filename)
+		}
+		testFile = s.Filename
+	} else {
+		alternate := s.AlternateFile()
+		if alternate == "" {
+			return nil, fmt.Errorf("no test file found for %s, create it first", s.Filename)
+		}
+		rel, err := filepath.Rel(s.Project.Root, alternate)
+		if err != nil {
+			return nil, err
+		}
+		testFile = filepath.ToSlash(rel)
+	}
+
+	methods, err := sourceFile.PublicMethods()
+	if err != nil {
+		return nil, err
+	}
+
+	target := filepath.Join(s.Project.Root, filepath.FromSlash(testFile))
+	data, err := os.ReadFile(target)
+	if err != nil {
+		return nil, err
+	}
+	content := string(data)
+	blocks := ScanRuby(content)
+	rspec := s.Project.FrameworkFor(testFile) == RSpec
+
+	container, ok := syncContainer(blocks, rspec)
+	if !ok || container.EndLine == container.Line {
+		return nil, fmt.Errorf("no top-level describe block or test class in %s", testFile)
+	}
+	indent := strings.Repeat(" ", container.Indent+2)
+
+	result := &SyncResult{File: testFile}
+	var skeletons []string
+	for _, method := range methods {
+		name, skeleton := missingSkeleton(blocks, method, rspec, indent)
+		if skeleton == "" || contains(result.Added, name) {
+			continue
+		}
+		result.Added = append(result.Added, name)
+		skeletons = append(skeletons, skeleton)
+	}
+	if len(skeletons) == 0 {
+		return result, nil
+	}
+
+	// Insert before the line closing the container, separated from the
+	// block above by a blank line
+	lines := strings.Split(content, "\n")
+	end := container.EndLine - 1
+	var inserted []string
+	if end > container.Line && strings.TrimSpace(lines[end-1]) != "" {
+		inserted = append(inserted, "")
+	}
+	result.Line = end + len(inserted) + 1
+	for i, skeleton := range skeletons {
+		if i > 0 {
+			inserted = append(inserted, "")
+		}
+		inserted = append(inserted, strings.Split(skeleton, "\n")...)
+	}
+	updated := append(append(append([]string{}, lines[:end]...), inserted...), lines[end:]...)
+
+	if err := os.WriteFile(target, []byte(strings.Join(updated, "\n")), 0644); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// syncContainer returns the block skeletons are added to: the first
+// describe block of a spec, or the first test class of a Minitest test
+func syncContainer(blocks []RubyBlock, rspec bool) (RubyBlock, bool) {
+	for _, block := range blocks {
+		if rspec && block.Kind == BlockDescribe {
+			return block, true
+		}
+		if !rspec && block.Kind == BlockClass && strings.HasSuffix(block.Name, "Test") {
+			return block, true
+		}
+	}
+	return RubyBlock{}, false
+}
+
+// missingSkeleton returns the name and skeleton of the test block for a
+// method, or "" when the test already has one
+func missingSkeleton(blocks []RubyBlock, method RubyBlock, rspec bool, indent string) (string, string) {
+	if _, ok := MethodTest(blocks, method, rspec); ok {
+		return "", ""
+	}
+	if rspec {
+		name := method.DescribeName()
+		return name, fmt.Sprintf("%sdescribe %q do\n%s  pending \"add examples for %s\"\n%send", indent, name, indent, name, indent)
+	}
+
+	if !testNameRegex.MatchString(method.Name) {
+		return "", ""
+	}
+	test := "test_" + method.TestedName()
+	return test, fmt.Sprintf("%sdef %s\n%s  skip \"add tests for %s\"\n%send", indent, test, indent, method.DescribeName(), indent)
+}
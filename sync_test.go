@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const syncSource = `class Invoice
+  attr_reader :items
+
+  def self.build
+    new
+  end
+
+  def initialize
+    @items = []
+  end
+
+  def charge!
+    true
+  end
+
+  def total = items.sum
+
+  def paid?
+    false
+  end
+
+  protected
+
+  def compare(other)
+    other
+  end
+
+  private
+
+  def helper
+    def nested; end
+  end
+
+  public
+
+  def refund
+    true
+  end
+
+  def self.secret; end
+  private_class_method :secret
+
+  def hidden; end
+  private :hidden
+end
+`
+
+func TestSourceFile_PublicMethods(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "app", "models", "invoice.rb"), syncSource)
+
+	methods, err := NewSourceFile("app/models/invoice.rb", NewProject(dir)).PublicMethods()
+	if err != nil {
+		t.Fatalf("PublicMethods() unexpected error: %v", err)
+	}
+	var got []string
+	for _, method := range methods {
+		got = append(got, method.DescribeName())
+	}
+	expected := []string{".build", "#charge!", "#total", "#paid?", "#refund"}
+	if !equalStrings(got, expected) {
+		t.Errorf("PublicMethods() = %v, want %v", got, expected)
+	}
+}
+
+func TestSourceFile_SyncTests(t *testing.T) {
+	tests := []struct {
+		name     string
+		rspec    bool
+		test     string
+		existing string
+		expected string
+		added    []string
+		line     int
+	}{
+		{
+			name:  "rspec",
+			rspec: true,
+			test:  "spec/models/invoice_spec.rb",
+			existing: `require "rails_helper"
+
+RSpec.describe Invoice do
+  describe "#charge!" do
+    it "charges" do
+    end
+  end
+end
+`,
+			expected: `require "rails_helper"
+
+RSpec.describe Invoice do
+  describe "#charge!" do
+    it "charges" do
+    end
+  end
+
+  describe ".build" do
+    pending "add examples for .build"
+  end
+
+  describe "#total" do
+    pending "add examples for #total"
+  end
+
+  describe "#paid?" do
+    pending "add examples for #paid?"
+  end
+
+  describe "#refund" do
+    pending "add examples for #refund"
+  end
+end
+`,
+			added: []string{".build", "#total", "#paid?", "#refund"},
+			line:  9,
+		},
+		{
+			name:  "empty spec",
+			rspec: true,
+			test:  "spec/models/invoice_spec.rb",
+			existing: `RSpec.describe Invoice do
+end
+`,
+			expected: `RSpec.describe Invoice do
+  describe ".build" do
+    pending "add examples for .build"
+  end
+
+  describe "#charge!" do
+    pending "add examples for #charge!"
+  end
+
+  describe "#total" do
+    pending "add examples for #total"
+  end
+
+  describe "#paid?" do
+    pending "add examples for #paid?"
+  end
+
+  describe "#refund" do
+    pending "add examples for #refund"
+  end
+end
+`,
+			added: []string{".build", "#charge!", "#total", "#paid?", "#refund"},
+			line:  2,
+		},
+		{
+			name: "minitest",
+			test: "test/models/invoice_test.rb",
+			existing: `require "test_helper"
+
+class InvoiceTest < ActiveSupport::TestCase
+  test "charge! succeeds" do
+    assert true
+  end
+
+  def test_build
+    assert true
+  end
+end
+`,
+			expected: `require "test_helper"
+
+class InvoiceTest < ActiveSupport::TestCase
+  test "charge! succeeds" do
+    assert true
+  end
+
+  def test_build
+    assert true
+  end
+
+  def test_total
+    skip "add tests for #total"
+  end
+
+  def test_paid
+    skip "add tests for #paid?"
+  end
+
+  def test_refund
+    skip "add tests for #refund"
+  end
+end
+`,
+			added: []string{"test_total", "test_paid", "test_refund"},
+			line:  12,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.rspec {
+				writeFile(t, filepath.Join(dir, ".rspec"), "")
+			}
+			writeFile(t, filepath.Join(dir, "app", "models", "invoice.rb"), syncSource)
+			writeFile(t, filepath.Join(dir, tt.test), tt.existing)
+			project := NewProject(dir)
+
+			result, err := NewSourceFile("app/models/invoice.rb", project).SyncTests()
+			if err != nil {
+				t.Fatalf("SyncTests() unexpected error: %v", err)
+			}
+			if result.File != tt.test || result.Line != tt.line || !equalStrings(result.Added, tt.added) {
+				t.Errorf("SyncTests() = %+v, want file %q, line %d, added %v", result, tt.test, tt.line, tt.added)
+			}
+			data, err := os.ReadFile(filepath.Join(dir, tt.test))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("test file =\n%s\nwant\n%s", data, tt.expected)
+			}
+
+			// Syncing again, from the test, adds nothing
+			result, err = NewSourceFile(tt.test, project).SyncTests()
+			if err != nil {
+				t.Fatalf("SyncTests() unexpected error: %v", err)
+			}
+			if len(result.Added) != 0 {
+				t.Errorf("second SyncTests() added %v, want nothing", result.Added)
+			}
+		})
+	}
+}
+
+func TestSourceFile_SyncTestsSingletonClass(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".rspec"), "")
+	writeFile(t, filepath.Join(dir, "app", "models", "session.rb"), `class Session
+  class << self
+    def find_by_token(token)
+      new
+    end
+
+    def revoke_all; end
+    private :revoke_all
+
+    private
+
+    def digest(token)
+      token
+    end
+  end
+
+  def expire
+  end
+end
+`)
+	writeFile(t, filepath.Join(dir, "spec", "models", "session_spec.rb"), "RSpec.describe Session do\nend\n")
+
+	result, err := NewSourceFile("app/models/session.rb", NewProject(dir)).SyncTests()
+	if err != nil {
+		t.Fatalf("SyncTests() unexpected error: %v", err)
+	}
+	if expected := []string{".find_by_token", "#expire"}; !equalStrings(result.Added, expected) {
+		t.Errorf("SyncTests() added %v, want %v", result.Added, expected)
+	}
+}
+
+func TestCLI_RunSync(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".rspec"), "")
+	writeFile(t, filepath.Join(dir, "app", "models", "invoice.rb"), "class Invoice\n  def total\n  end\nend\n")
+
+	cli := &CLI{Command: "sync", Root: dir, Path: "app/models/invoice.rb", Print: true, Stdout: &bytes.Buffer{}}
+	if err := cli.Run(); err == nil {
+		t.Error("Run() expected an error without a test file")
+	}
+
+	writeFile(t, filepath.Join(dir, "spec", "models", "invoice_spec.rb"), "RSpec.describe Invoice do\nend\n")
+	var out bytes.Buffer
+	cli = &CLI{Command: "sync", Root: dir, Path: "app/models/invoice.rb", Print: true, Stdout: &out}
+	if err := cli.Run(); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if got, want := out.String(), filepath.Join(dir, "spec/models/invoice_spec.rb")+":2\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// templateExtensions are the extensions of view templates
+var templateExtensions = []string{".erb", ".haml", ".slim"}
+
+// isTemplate reports whether the file is a view template, such as
+// show.html.erb or _form.html.haml
+func isTemplate(file string) bool {
+	for _, ext := range templateExtensions {
+		if strings.HasSuffix(file, ext) && len(path.Base(file)) > len(ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsView checks if the file is a Rails view template or partial
+func (s *SourceFile) IsView() bool {
+	return strings.HasPrefix(s.Filename, "app/views/") && isTemplate(s.Filename)
+}
+
+// IsHelper checks if the file is a Rails view helper
+func (s *SourceFile) IsHelper() bool {
+	return strings.HasPrefix(s.Filename, "app/helpers/") && strings.HasSuffix(s.Filename, "_helper.rb")
+}
+
+// resource returns the controller path a view, controller or helper
+// belongs to, e.g. "api/v1/users", or ""
+func (s *SourceFile) resource() string {
+	switch {
+	case s.IsView():
+		dir := path.Dir(strings.TrimPrefix(s.Filename, "app/views/"))
+		if dir == "." {
+			return ""
+		}
+		return dir
+	case s.IsController():
+		return strings.TrimSuffix(strings.TrimPrefix(s.Filename, "app/controllers/"), "_controller.rb")
+	case s.IsHelper():
+		return strings.TrimSuffix(strings.TrimPrefix(s.Filename, "app/helpers/"), "_helper.rb")
+	}
+	return ""
+}
+
+// Action returns the controller action a view renders, e.g. "show" for
+// show.html.erb, or "" for partials and other files
+func (s *SourceFile) Action() string {
+	if !s.IsView() {
+		return ""
+	}
+	base := path.Base(s.Filename)
+	if strings.HasPrefix(base, "_") {
+		return ""
+	}
+	return strings.SplitN(base, ".", 2)[0]
+}
+
+// relatedSources returns the existing source files related to a view,
+// controller or helper, relative to the project root: the controller and
+// helper of a view, the views and helper of a controller, and the
+// controller of a helper
+func (s *SourceFile) relatedSources() []string {
+	resource := s.resource()
+	if resource == "" {
+		return nil
+	}
+	controller := path.Join("app", "controllers", resource+"_controller.rb")
+	helper := path.Join("app", "helpers", resource+"_helper.rb")
+
+	var related []string
+	switch {
+	case s.IsView():
+		related = append(related, controller, helper)
+	case s.IsController():
+		related = append(related, s.Project.views(resource)...)
+		related = append(related, helper)
+	case s.IsHelper():
+		related = append(related, controller)
+	}
+
+	var existing []string
+	for _, file := range related {
+		if fileExists(filepath.Join(s.Project.Root, filepath.FromSlash(file))) {
+			existing = append(existing, file)
+		}
+	}
+	return existing
+}
+
+// views returns the templates in a controller's view directory, sorted
+func (p *Project) views(resource string) []string {
+	dir := path.Join("app", "views", resource)
+	entries, err := os.ReadDir(filepath.Join(p.Root, filepath.FromSlash(dir)))
+	if err != nil {
+		return nil
+	}
+
+	var views []string
+	for _, entry := range entries {
+		if !entry.IsDir() && isTemplate(entry.Name()) {
+			views = append(views, path.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(views)
+	return views
+}
+
+// ActionLine returns the line of the action a view renders in the given
+// controller, relative to the project root, or 0 when the file isn't the
+// view's controller or doesn't define the action
+func (s *SourceFile) ActionLine(controller string) int {
+	action := s.Action()
+	if action == "" || controller != path.Join("app", "controllers", s.resource()+"_controller.rb") {
+		return 0
+	}
+	blocks, err := ScanRubyFile(filepath.Join(s.Project.Root, filepath.FromSlash(controller)))
+	if err != nil {
+		return 0
+	}
+	for _, block := range blocks {
+		if block.Kind == BlockDef && !block.Singleton && block.Name == action {
+			return block.Line
+		}
+	}
+	return 0
+}
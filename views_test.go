@@ -0,0 +1,140 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+const usersController = `class UsersController < ApplicationController
+  def index
+  end
+
+  def show
+    @user = User.find(params[:id])
+  end
+end
+`
+
+// setupViewsProject creates a Rails RSpec app with a controller, its
+// helper, views and a view spec
+func setupViewsProject(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".rspec"), "")
+	writeFile(t, filepath.Join(dir, "app", "controllers", "users_controller.rb"), usersController)
+	writeFile(t, filepath.Join(dir, "app", "helpers", "users_helper.rb"), "")
+	writeFile(t, filepath.Join(dir, "app", "views", "users", "show.html.erb"), "")
+	writeFile(t, filepath.Join(dir, "app", "views", "users", "_form.html.haml"), "")
+	writeFile(t, filepath.Join(dir, "app", "views", "users", "index.json.jbuilder"), "")
+	writeFile(t, filepath.Join(dir, "spec", "views", "users", "show.html.erb_spec.rb"), "")
+	return dir
+}
+
+func TestSourceFile_ViewAlternates(t *testing.T) {
+	tests := []struct {
+		source string
+		test   string
+	}{
+		{"app/views/users/show.html.erb", "spec/views/users/show.html.erb_spec.rb"},
+		{"app/views/users/_form.html.haml", "spec/views/users/_form.html.haml_spec.rb"},
+		{"app/views/admin/users/edit.html.slim", "spec/views/admin/users/edit.html.slim_spec.rb"},
+		{"app/helpers/users_helper.rb", "spec/helpers/users_helper_spec.rb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, filepath.Join(dir, ".rspec"), "")
+			writeFile(t, filepath.Join(dir, tt.source), "")
+			project := NewProject(dir)
+
+			if got := NewSourceFile(tt.source, project).PreferredTestPath(); got != tt.test {
+				t.Errorf("PreferredTestPath() = %q, want %q", got, tt.test)
+			}
+			writeFile(t, filepath.Join(dir, tt.test), "")
+
+			test := NewSourceFile(tt.test, project)
+			if !test.IsTestFile() {
+				t.Errorf("IsTestFile(%q) = false, want true", tt.test)
+			}
+			if got, want := test.AlternateFile(), filepath.Join(dir, tt.source); got != want {
+				t.Errorf("AlternateFile() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestSourceFile_Action(t *testing.T) {
+	project := NewProject(t.TempDir())
+	tests := map[string]string{
+		"app/views/users/show.html.erb":     "show",
+		"app/views/users/index.json.erb":    "index",
+		"app/views/users/_form.html.erb":    "",
+		"app/models/user.rb":                "",
+		"app/views/users/edit.html.slim":    "edit",
+		"app/views/layouts/application.erb": "application",
+	}
+	for file, expected := range tests {
+		if got := NewSourceFile(file, project).Action(); got != expected {
+			t.Errorf("Action(%q) = %q, want %q", file, got, expected)
+		}
+	}
+}
+
+func TestSourceFile_RelatedViews(t *testing.T) {
+	dir := setupViewsProject(t)
+	project := NewProject(dir)
+
+	tests := []struct {
+		file     string
+		expected []string
+	}{
+		{
+			file: "app/views/users/show.html.erb",
+			expected: []string{
+				"app/views/users/show.html.erb",
+				"spec/views/users/show.html.erb_spec.rb",
+				"app/controllers/users_controller.rb",
+				"app/helpers/users_helper.rb",
+			},
+		},
+		{
+			file: "app/controllers/users_controller.rb",
+			expected: []string{
+				"app/controllers/users_controller.rb",
+				"app/views/users/_form.html.haml",
+				"app/views/users/show.html.erb",
+				"app/helpers/users_helper.rb",
+			},
+		},
+		{
+			file: "app/helpers/users_helper.rb",
+			expected: []string{
+				"app/helpers/users_helper.rb",
+				"app/controllers/users_controller.rb",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := NewSourceFile(tt.file, project).RelatedFiles(); !equalStrings(got, tt.expected) {
+			t.Errorf("RelatedFiles(%q) = %v, want %v", tt.file, got, tt.expected)
+		}
+	}
+}
+
+func TestSourceFile_ActionLine(t *testing.T) {
+	dir := setupViewsProject(t)
+	project := NewProject(dir)
+	controller := "app/controllers/users_controller.rb"
+
+	if got := NewSourceFile("app/views/users/show.html.erb", project).ActionLine(controller); got != 5 {
+		t.Errorf("ActionLine() = %d, want 5", got)
+	}
+	if got := NewSourceFile("app/views/users/_form.html.haml", project).ActionLine(controller); got != 0 {
+		t.Errorf("ActionLine() = %d for a partial, want 0", got)
+	}
+	if got := NewSourceFile("app/views/users/show.html.erb", project).ActionLine("app/helpers/users_helper.rb"); got != 0 {
+		t.Errorf("ActionLine() = %d for the helper, want 0", got)
+	}
+}